@@ -1,9 +1,9 @@
 package audible
 
 import (
+	"context"
 	"encoding/json"
-	"io"
-	"log"
+	"fmt"
 	"net/http"
 	"net/url"
 
@@ -14,6 +14,7 @@ const (
 	apiHost        = `api.audible`
 	apiPath        = `/1.0/catalog/products`
 	responseGroups = `media,product_desc,contributors,series,product_extended_attrs,product_attrs`
+	chapterGroup   = `chapter_info`
 )
 
 type ApiRequest struct {
@@ -22,19 +23,7 @@ type ApiRequest struct {
 	asin   []string
 }
 
-var audibleClient = &http.Client{}
-
-func NewRequest() *AudibleQuery {
-	return &AudibleQuery{
-		query: &query{
-			suffix: ".com",
-		},
-		IsApi: true,
-		api: &ApiRequest{
-			client: audibleClient,
-		},
-	}
-}
+var audibleClient = &http.Client{Timeout: clientOpts.Timeout}
 
 func NewApiRequest() *ApiRequest {
 	return &ApiRequest{
@@ -42,48 +31,45 @@ func NewApiRequest() *ApiRequest {
 	}
 }
 
-func (a *ApiRequest) makeRequest(u string) map[string]json.RawMessage {
-	resp, err := audibleClient.Get(u)
+func (a *ApiRequest) makeRequest(ctx context.Context, u string) (map[string]json.RawMessage, error) {
+	body, err := fetch(ctx, a.client, cacheApi, u)
 	if err != nil {
-		log.Fatal(err)
-	}
-	defer resp.Body.Close()
-
-	body, errr := io.ReadAll(resp.Body)
-	if errr != nil {
-		log.Fatal(errr)
+		return nil, err
 	}
 
 	var result map[string]json.RawMessage
-	uerr := json.Unmarshal(body, &result)
-	if uerr != nil {
-		log.Fatalf("failed to unmarshal audible api search %v\n", uerr)
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal audible api response %v\n", err)
 	}
 
-	return result
+	return result, nil
 }
 
-func (a *ApiRequest) getBook(req string) *book.Book {
-	result := a.makeRequest(req)
+func (a *ApiRequest) getBook(ctx context.Context, req string) (*book.Book, error) {
+	result, err := a.makeRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 	return book.UnmarshalAudibleApiProduct(result["product"])
 }
 
-//func (a *ApiRequest) search(req string) []*book.Book {
-//}
-
-func (a *ApiRequest) searchResults(req string) []string {
-	result := a.makeRequest(req)
+func (a *ApiRequest) searchResults(ctx context.Context, req string) ([]string, error) {
+	result, err := a.makeRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 
 	var total int
-	err := json.Unmarshal(result["total_results"], &total)
+	err = json.Unmarshal(result["total_results"], &total)
 	if err != nil {
-		log.Fatalf("failed to unmarshal total results %v\n", err)
+		return nil, fmt.Errorf("failed to unmarshal total results %v\n", err)
 	}
 
 	var products []map[string]string
 	err = json.Unmarshal(result["products"], &products)
 	if err != nil {
-		log.Fatalf("failed to unmarshal products %v\n", err)
+		return nil, fmt.Errorf("failed to unmarshal products %v\n", err)
 	}
 
 	var asin []string
@@ -91,5 +77,5 @@ func (a *ApiRequest) searchResults(req string) []string {
 		asin = append(asin, p["asin"])
 	}
 
-	return asin
+	return asin, nil
 }
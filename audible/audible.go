@@ -1,8 +1,8 @@
 package audible
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"net/url"
 	"path"
 	"strconv"
@@ -18,6 +18,7 @@ type query struct {
 	countryCode string
 	values      url.Values
 	asin        string
+	chapters    bool
 	IsApi       bool
 	IsWeb       bool
 }
@@ -53,7 +54,11 @@ func (q *query) string() string {
 	}
 
 	if q.asin != "" {
-		q.values.Set("response_groups", responseGroups)
+		groups := responseGroups
+		if q.chapters {
+			groups = groups + "," + chapterGroup
+		}
+		q.values.Set("response_groups", groups)
 		q.Path = path.Join(apiPath, q.asin)
 	}
 
@@ -64,6 +69,7 @@ func (q *query) string() string {
 
 type AudibleQuery struct {
 	cliArgs
+	ctx     context.Context
 	query   *query
 	api     *ApiRequest
 	scraper *WebScraper
@@ -78,10 +84,13 @@ type cliArgs struct {
 	Narrators string
 	Title     string
 	IsBatch   bool
+	Chapters  bool
+	Progress  bool
 }
 
 func NewQuery() *AudibleQuery {
 	audible := &AudibleQuery{
+		ctx:     context.Background(),
 		IsApi:   true,
 		api:     NewApiRequest(),
 		scraper: newScraper(),
@@ -90,34 +99,49 @@ func NewQuery() *AudibleQuery {
 	return audible
 }
 
-func (q *AudibleQuery) GetBook() *book.Book {
-	q.parseCliUrl()
+func (q *AudibleQuery) WithContext(ctx context.Context) *AudibleQuery {
+	q.ctx = ctx
+	q.scraper.ctx = ctx
+	return q
+}
+
+func (q *AudibleQuery) GetBook() (*book.Book, error) {
+	_, err := q.parseCliUrl()
+	if err != nil {
+		return nil, err
+	}
 
 	var b *book.Book
 
 	if q.IsApi {
-		b = q.api.getBook(q.query.string())
+		q.query.chapters = q.Chapters
+		b, err = q.api.getBook(q.ctx, q.query.string())
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", q.query.asin, err)
+		}
 	}
 
 	if q.IsWeb {
-		b = q.scraper.getBook(q.Url)
+		b, err = q.scraper.getBook(q.Url)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return b
+	return b, nil
 }
 
-func (q *AudibleQuery) GetBookBatch() []*book.Book {
-	q.parseCliUrl()
-
-	urls := q.scraper.getListURLs(q.Url)
-
-	var b []*book.Book
+// GetBookBatch fetches every book in an audible list, a failed book doesn't
+// stop the batch, the failed asins are reported in a *BatchError.
+func (q *AudibleQuery) GetBookBatch() ([]*book.Book, error) {
+	_, err := q.parseCliUrl()
+	if err != nil {
+		return nil, err
+	}
 
-	if q.IsApi {
-		for _, u := range urls {
-			q.query.asin = getAsin(u)
-			b = append(b, q.api.getBook(q.query.string()))
-		}
+	urls, err := q.scraper.getListURLs(q.Url)
+	if err != nil {
+		return nil, err
 	}
 
 	if q.IsWeb {
@@ -127,59 +151,147 @@ func (q *AudibleQuery) GetBookBatch() []*book.Book {
 			q.query.Path = u
 			webUrls = append(webUrls, q.query.string())
 		}
-		return q.scraper.scrapeUrls(webUrls...)
+		return q.scrapeUrls(webUrls)
+	}
+
+	var asins []string
+	for _, u := range urls {
+		asins = append(asins, getAsin(u))
+	}
+
+	return q.getBooks(asins)
+}
+
+func (q *AudibleQuery) getBooks(asins []string) ([]*book.Book, error) {
+	q.query.chapters = q.Chapters
+	q.query.values = url.Values{}
+
+	urls := make([]string, len(asins))
+	for i, asin := range asins {
+		q.query.asin = asin
+		urls[i] = q.query.string()
+	}
+
+	ctx, cancel := context.WithCancel(q.ctx)
+	defer cancel()
+
+	bar := q.progress("fetching from audible", len(urls), cancel)
+	books := make([]*book.Book, len(urls))
+	errs := runPool(ctx, len(urls), bar, func(ctx context.Context, i int) error {
+		var err error
+		books[i], err = q.api.getBook(ctx, urls[i])
+		return err
+	})
+	if bar != nil {
+		bar.Done()
+	}
+
+	var (
+		b        []*book.Book
+		batchErr = newBatchError(len(asins))
+	)
+	for i, err := range errs {
+		if err != nil {
+			batchErr.add(asins[i], err)
+			continue
+		}
+		b = append(b, books[i])
+	}
+
+	return b, batchErr.errOrNil()
+}
+
+func (q *AudibleQuery) scrapeUrls(urls []string) ([]*book.Book, error) {
+	ctx, cancel := context.WithCancel(q.ctx)
+	defer cancel()
+
+	q.scraper.ctx = ctx
+	q.scraper.progress = q.progress("scraping audible", len(urls), cancel)
+	defer func() {
+		if q.scraper.progress != nil {
+			q.scraper.progress.Done()
+			q.scraper.progress = nil
+		}
+		q.scraper.ctx = q.ctx
+	}()
+
+	return q.scraper.scrapeUrls(urls...)
+}
+
+func (q *AudibleQuery) progress(title string, total int, cancel func()) *bubbles.Progress {
+	if !q.Progress || total < 2 {
+		return nil
 	}
-	return b
+	return bubbles.NewProgress(title, total).OnCancel(cancel).Start()
 }
 
-func (q *AudibleQuery) Search() []*book.Book {
-	var b []*book.Book
+func (q *AudibleQuery) Search() ([]*book.Book, error) {
+	b, err := q.Candidates()
+	if len(b) > 1 {
+		b = q.selectResults(b)
+	}
+	return b, err
+}
 
+// Candidates returns every search result without prompting for a choice.
+func (q *AudibleQuery) Candidates() ([]*book.Book, error) {
 	switch {
 	case q.IsWeb:
 		q.query = newScraperQuery()
 		q.query.values = q.parseCliSearch()
+		list, err := q.scraper.getListURLs(q.query.string())
+		if err != nil {
+			return nil, err
+		}
 		var urls []string
-		for _, u := range q.scraper.getListURLs(q.query.string()) {
+		for _, u := range list {
 			q.query.values = url.Values{}
 			q.query.Path = u
-			println(q.query.string())
 			urls = append(urls, q.query.string())
 		}
-		b = q.scraper.scrapeUrls(urls...)
+		return q.scrapeUrls(urls)
 	case q.IsApi:
+		q.query.asin = ""
+		q.query.Path = apiPath
 		q.query.values = q.parseCliSearch()
-		results := q.api.searchResults(q.query.string())
-		for _, result := range results {
-			q.query.asin = result
-			b = append(b, q.api.getBook(q.query.string()))
+		results, err := q.api.searchResults(q.ctx, q.query.string())
+		if err != nil {
+			return nil, err
 		}
+		return q.getBooks(results)
 	}
-
-	if len(b) > 1 {
-		b = q.selectResults(b)
-	}
-
-	return b
+	return nil, nil
 }
 
 func (q *AudibleQuery) selectResults(books []*book.Book) []*book.Book {
-	var selected []*book.Book
-	choices := make(map[string]string)
-	idx := make(map[string]*book.Book)
-
-	for i, c := range books {
-		title := c.GetField("title").String()
-		authors := c.GetField("authors").String()
-		text := fmt.Sprintf("%s by %s", title, authors)
-		num := strconv.Itoa(i)
-		choices[text] = num
-		idx[num] = c
+	var choices []bubbles.Choice
+	for i, b := range books {
+		cover := "no cover"
+		if b.GetFile("cover").Get("url") != "" {
+			cover = "cover"
+		}
+		choices = append(choices, bubbles.Choice{
+			ID:    strconv.Itoa(i),
+			Title: fmt.Sprintf("%s by %s", b.GetMeta("title"), b.GetMeta("authors")),
+			Columns: []string{
+				b.GetMeta("#narrators"),
+				b.GetSeriesString(),
+				b.GetMeta("#duration"),
+				b.GetMeta("published"),
+				cover,
+			},
+			Preview: b.ConvertTo("markdown").String(),
+		})
 	}
 
-	choice := bubbles.NewPrompt("search results: pick one", choices).Choose()
-
-	selected = append(selected, idx[choice])
+	var selected []*book.Book
+	prompt := bubbles.NewChoicePrompt("search results: pick one or more with space", choices).Multi()
+	for _, id := range prompt.ChooseMany() {
+		idx, err := strconv.Atoi(id)
+		if err == nil {
+			selected = append(selected, books[idx])
+		}
+	}
 	return selected
 }
 
@@ -215,10 +327,10 @@ func (q *AudibleQuery) parseCliSearch() url.Values {
 	return query
 }
 
-func (q *AudibleQuery) parseCliUrl() *AudibleQuery {
+func (q *AudibleQuery) parseCliUrl() (*AudibleQuery, error) {
 	aURL, err := url.Parse(q.Url)
 	if err != nil {
-		log.Fatal(err)
+		return q, fmt.Errorf("%v is not a valid audible url: %w", q.Url, err)
 	}
 
 	if !q.IsBatch {
@@ -229,7 +341,7 @@ func (q *AudibleQuery) parseCliUrl() *AudibleQuery {
 	q.query.countryCode = host[len(host)-1]
 	q.query.suffix = countrySuffix(q.query.countryCode)
 
-	return q
+	return q, nil
 }
 
 func (args *cliArgs) SetKeywords(words []string) *cliArgs {
@@ -256,3 +368,13 @@ func (args *cliArgs) SetTitle(words string) *cliArgs {
 	args.Title = words
 	return args
 }
+
+func (args *cliArgs) SetChapters(chaps bool) *cliArgs {
+	args.Chapters = chaps
+	return args
+}
+
+func (args *cliArgs) SetProgress(show bool) *cliArgs {
+	args.Progress = show
+	return args
+}
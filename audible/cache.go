@@ -0,0 +1,298 @@
+package audible
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	cacheApi   = "api"
+	cacheHtml  = "html"
+	cacheCover = "cover"
+)
+
+func cacheKinds() []string {
+	return []string{cacheApi, cacheHtml, cacheCover}
+}
+
+// Cache stores audible responses on disk, each response is kept as a body
+// file next to a json file describing it.
+type Cache struct {
+	dir      string
+	ttl      map[string]time.Duration
+	refresh  bool
+	disabled bool
+	mtx      sync.Mutex
+}
+
+type CacheEntry struct {
+	URL     string        `json:"url"`
+	Kind    string        `json:"kind"`
+	Fetched time.Time     `json:"fetched"`
+	Size    int64         `json:"size"`
+	TTL     time.Duration `json:"-"`
+	path    string
+}
+
+var cache = newCache()
+
+func newCache() *Cache {
+	var dir string
+	if d, err := os.UserCacheDir(); err == nil {
+		dir = filepath.Join(d, "urbooks", "audible")
+	}
+	return &Cache{
+		dir: dir,
+		ttl: map[string]time.Duration{
+			cacheApi:   7 * 24 * time.Hour,
+			cacheHtml:  7 * 24 * time.Hour,
+			cacheCover: 30 * 24 * time.Hour,
+		},
+		disabled: dir == "",
+	}
+}
+
+func ResponseCache() *Cache {
+	return cache
+}
+
+func cacheConfig(v *viper.Viper) {
+	if v == nil {
+		return
+	}
+	if v.IsSet("dir") {
+		cache.SetDir(v.GetString("dir"))
+	}
+	if v.GetBool("disabled") {
+		cache.Disable()
+	}
+	for _, kind := range cacheKinds() {
+		if key := "ttl." + kind; v.IsSet(key) {
+			cache.SetTTL(kind, v.GetDuration(key))
+		}
+	}
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) SetDir(dir string) *Cache {
+	c.dir = dir
+	c.disabled = dir == ""
+	return c
+}
+
+// SetRefresh skips cached responses, fresh responses are still stored.
+func (c *Cache) SetRefresh(refresh bool) *Cache {
+	c.refresh = refresh
+	return c
+}
+
+func (c *Cache) SetTTL(kind string, ttl time.Duration) *Cache {
+	c.ttl[kind] = ttl
+	return c
+}
+
+func (c *Cache) Disable() *Cache {
+	c.disabled = true
+	return c
+}
+
+func (c *Cache) get(kind, u string) ([]byte, bool) {
+	if c.disabled || c.refresh {
+		return nil, false
+	}
+
+	entry, err := c.readEntry(c.entryPath(kind, u))
+	if err != nil || entry.Expired() {
+		return nil, false
+	}
+
+	body, err := os.ReadFile(entry.path)
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+func (c *Cache) has(kind, u string) bool {
+	if c.disabled || c.refresh {
+		return false
+	}
+	entry, err := c.readEntry(c.entryPath(kind, u))
+	return err == nil && !entry.Expired()
+}
+
+func (c *Cache) set(kind, u string, body []byte) error {
+	if c.disabled {
+		return nil
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	path := c.entryPath(kind, u)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path, body, 0644)
+	if err != nil {
+		return err
+	}
+
+	entry := CacheEntry{
+		URL:     normalizeURL(u),
+		Kind:    kind,
+		Fetched: time.Now(),
+		Size:    int64(len(body)),
+	}
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+".json", meta, 0644)
+}
+
+func (c *Cache) delete(kind, u string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	path := c.entryPath(kind, u)
+	os.Remove(path)
+	os.Remove(path + ".json")
+}
+
+func (c *Cache) entryPath(kind, u string) string {
+	sum := sha256.Sum256([]byte(normalizeURL(u)))
+	return filepath.Join(c.dir, kind, hex.EncodeToString(sum[:]))
+}
+
+func (c *Cache) readEntry(path string) (CacheEntry, error) {
+	var entry CacheEntry
+	meta, err := os.ReadFile(path + ".json")
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(meta, &entry)
+	if err != nil {
+		return entry, err
+	}
+	entry.path = path
+	entry.TTL = c.ttl[entry.Kind]
+	return entry, nil
+}
+
+// Entries lists everything in the cache, oldest first.
+func (c *Cache) Entries() ([]CacheEntry, error) {
+	var entries []CacheEntry
+	for _, kind := range cacheKinds() {
+		files, err := filepath.Glob(filepath.Join(c.dir, kind, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			entry, err := c.readEntry(strings.TrimSuffix(f, ".json"))
+			if err != nil {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Fetched.Before(entries[j].Fetched)
+	})
+	return entries, nil
+}
+
+// Prune removes expired entries, or every entry when all is true, and
+// returns the number of entries removed.
+func (c *Cache) Prune(all bool) (int, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return 0, err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var removed int
+	for _, entry := range entries {
+		if all || entry.Expired() {
+			if err := os.Remove(entry.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return removed, err
+			}
+			if err := os.Remove(entry.path + ".json"); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (e CacheEntry) Age() time.Duration {
+	return time.Since(e.Fetched)
+}
+
+func (e CacheEntry) Expired() bool {
+	return e.TTL > 0 && e.Age() > e.TTL
+}
+
+// normalizeURL makes equivalent urls share a cache key: the scheme and host
+// are lowercased, the fragment dropped and the query params sorted.
+func normalizeURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+	parsed.RawQuery = parsed.Query().Encode()
+	return parsed.String()
+}
+
+// htmlCache lets the geziyor scraper share the disk cache, it stores the
+// dumped http responses.
+type htmlCache struct {
+	*Cache
+}
+
+func (c htmlCache) Get(key string) ([]byte, bool) {
+	return c.get(cacheHtml, key)
+}
+
+func (c htmlCache) Set(key string, resp []byte) {
+	r, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(resp)), nil)
+	if err != nil {
+		return
+	}
+	r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return
+	}
+	if err := c.set(cacheHtml, key, resp); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to cache %v: %v\n", key, err)
+	}
+}
+
+func (c htmlCache) Delete(key string) {
+	c.delete(cacheHtml, key)
+}
@@ -0,0 +1,87 @@
+package audible
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+)
+
+type offline struct{}
+
+func (offline) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("tests don't go online")
+}
+
+func testCache(t *testing.T) {
+	t.Helper()
+	dir := cache.Dir()
+	cache.SetDir(t.TempDir())
+	t.Cleanup(func() { cache.SetDir(dir) })
+}
+
+func TestCachedApiBook(t *testing.T) {
+	testCache(t)
+
+	q := newApiQuery()
+	q.asin = "B002V0QK4C"
+	u := q.string()
+
+	body, err := os.ReadFile("testdata/product.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.set(cacheApi, u, body); err != nil {
+		t.Fatal(err)
+	}
+
+	api := &ApiRequest{client: &http.Client{Transport: offline{}}}
+	b, err := api.getBook(context.Background(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for field, want := range map[string]string{
+		"title":      "The Hobbit",
+		"authors":    "J. R. R. Tolkien",
+		"#narrators": "Andy Serkis",
+		"series":     "The Lord of the Rings",
+		"position":   "0.5",
+		"publisher":  "Recorded Books",
+	} {
+		if got := b.GetField(field).String(); got != want {
+			t.Errorf("%v = %q, want %q", field, got, want)
+		}
+	}
+}
+
+func TestCacheEntryOmitsTTL(t *testing.T) {
+	testCache(t)
+
+	u := "https://api.audible.com/1.0/catalog/products/B002V0QK4C"
+	if err := cache.set(cacheApi, u, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := os.ReadFile(cache.entryPath(cacheApi, u) + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(meta, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields["TTL"]; ok {
+		t.Errorf("ttl is written to the cache: %s", meta)
+	}
+
+	entries, err := cache.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].TTL != cache.ttl[cacheApi] {
+		t.Errorf("entries = %+v, want one with the api ttl", entries)
+	}
+}
@@ -0,0 +1,207 @@
+package audible
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type ClientOpts struct {
+	Timeout           time.Duration
+	Retries           int
+	Backoff           time.Duration
+	Concurrency       int
+	RequestsPerSecond float64
+}
+
+var clientOpts = ClientOpts{
+	Timeout:           30 * time.Second,
+	Retries:           3,
+	Backoff:           500 * time.Millisecond,
+	Concurrency:       4,
+	RequestsPerSecond: 5,
+}
+
+func Config(v *viper.Viper) {
+	if v == nil {
+		return
+	}
+	if v.IsSet("timeout") {
+		SetTimeout(v.GetDuration("timeout"))
+	}
+	if v.IsSet("retries") {
+		SetRetries(v.GetInt("retries"))
+	}
+	if v.IsSet("backoff") {
+		clientOpts.Backoff = v.GetDuration("backoff")
+	}
+	if v.IsSet("concurrency") {
+		SetConcurrency(v.GetInt("concurrency"))
+	}
+	if v.IsSet("requests_per_second") {
+		SetRequestsPerSecond(v.GetFloat64("requests_per_second"))
+	}
+	cacheConfig(v.Sub("cache"))
+}
+
+func SetTimeout(t time.Duration) {
+	clientOpts.Timeout = t
+	audibleClient.Timeout = t
+}
+
+func SetRetries(r int) {
+	clientOpts.Retries = r
+}
+
+type statusError struct {
+	url    string
+	code   int
+	status string
+	wait   time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%v returned %v", e.url, e.status)
+}
+
+func (e *statusError) retryable() bool {
+	return e.code == http.StatusTooManyRequests || e.code >= 500
+}
+
+// fetch returns the cached response for u if there is a fresh one, otherwise
+// it's downloaded and stored in the cache.
+func fetch(ctx context.Context, c *http.Client, kind, u string) ([]byte, error) {
+	if body, ok := cache.get(kind, u); ok {
+		return body, nil
+	}
+
+	body, err := download(ctx, c, u)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cache.set(kind, u, body); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to cache %v: %v\n", u, err)
+	}
+
+	return body, nil
+}
+
+func download(ctx context.Context, c *http.Client, u string) ([]byte, error) {
+	var (
+		err  error
+		wait = clientOpts.Backoff
+	)
+
+	for attempt := 0; attempt <= clientOpts.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			wait = wait * 2
+		}
+
+		var body []byte
+		body, err = fetchOnce(ctx, c, u)
+		if err == nil {
+			return body, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var sErr *statusError
+		if errors.As(err, &sErr) {
+			if !sErr.retryable() {
+				return nil, err
+			}
+			if sErr.wait > wait {
+				wait = sErr.wait
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("giving up after %d attempts: %w", clientOpts.Retries+1, err)
+}
+
+func fetchOnce(ctx context.Context, c *http.Client, u string) ([]byte, error) {
+	if err := limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, clientOpts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{
+			url:    u,
+			code:   resp.StatusCode,
+			status: resp.Status,
+			wait:   retryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func retryAfter(h string) time.Duration {
+	if secs, err := strconv.Atoi(h); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	return 0
+}
+
+type BatchError struct {
+	Total  int
+	Failed []string
+	Errs   map[string]error
+}
+
+func newBatchError(total int) *BatchError {
+	return &BatchError{
+		Total: total,
+		Errs:  make(map[string]error),
+	}
+}
+
+func (e *BatchError) add(asin string, err error) {
+	e.Failed = append(e.Failed, asin)
+	e.Errs[asin] = err
+}
+
+func (e *BatchError) Error() string {
+	var failed []string
+	for _, asin := range e.Failed {
+		failed = append(failed, fmt.Sprintf("%v: %v", asin, e.Errs[asin]))
+	}
+	return fmt.Sprintf("%d of %d asins failed\n%v", len(e.Failed), e.Total, strings.Join(failed, "\n"))
+}
+
+func (e *BatchError) errOrNil() error {
+	if len(e.Failed) > 0 {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package audible
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ohzqq/urbooks-core/book"
+)
+
+type Match struct {
+	Book      *book.Book
+	Score     float64
+	Title     float64
+	Authors   float64
+	Narrators float64
+	Duration  float64
+}
+
+var matchWeights = map[string]float64{
+	"title":     0.4,
+	"authors":   0.3,
+	"narrators": 0.15,
+	"duration":  0.15,
+}
+
+// Rank scores each candidate against the library book, best match first.
+func Rank(lib *book.Book, candidates []*book.Book) []Match {
+	var matches []Match
+	for _, c := range candidates {
+		matches = append(matches, Score(lib, c))
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+// Score compares a library book with an audible product. Each part scores
+// between 0 and 1, parts that are missing from either book are left out of
+// the weighted total instead of counting against it.
+func Score(lib, candidate *book.Book) Match {
+	m := Match{Book: candidate}
+
+	var total, weight float64
+	add := func(part string, score float64, ok bool) float64 {
+		if ok {
+			total += score * matchWeights[part]
+			weight += matchWeights[part]
+		}
+		return score
+	}
+
+	m.Title = add("title", titleSimilarity(lib.GetMeta("title"), candidate.GetMeta("title")), true)
+
+	libAuthors := fieldValues(lib, "authors")
+	m.Authors = add("authors", nameOverlap(libAuthors, fieldValues(candidate, "authors")), len(libAuthors) > 0)
+
+	libNarrators := fieldValues(lib, "#narrators")
+	candNarrators := fieldValues(candidate, "#narrators")
+	m.Narrators = add("narrators", nameOverlap(libNarrators, candNarrators), len(libNarrators) > 0 && len(candNarrators) > 0)
+
+	libDur := fieldDuration(lib)
+	candDur := fieldDuration(candidate)
+	m.Duration = add("duration", durationSimilarity(libDur, candDur), libDur > 0 && candDur > 0)
+
+	if weight > 0 {
+		m.Score = total / weight
+	}
+	return m
+}
+
+func (m Match) ASIN() string {
+	return m.Book.GetIdentifier("audible")
+}
+
+func fieldValues(b *book.Book, name string) []string {
+	f := b.GetField(name)
+	if f == nil || f.IsNull() {
+		return nil
+	}
+	if f.IsCollection() {
+		return f.Collection().StringSlice()
+	}
+	return []string{f.String()}
+}
+
+func fieldDuration(b *book.Book) time.Duration {
+	f := b.GetField("#duration")
+	if f == nil || f.IsNull() {
+		return 0
+	}
+	return parseDuration(f.String())
+}
+
+var nonWord = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+func normalize(s string) string {
+	s = strings.ToLower(s)
+	s = nonWord.ReplaceAllString(s, " ")
+	return strings.TrimSpace(s)
+}
+
+// titleSimilarity ignores subtitles when one title has one and the other
+// doesn't, audible often appends them.
+func titleSimilarity(a, b string) float64 {
+	a, b = normalize(a), normalize(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	sim := similarity(a, b)
+	if strings.HasPrefix(b, a) || strings.HasPrefix(a, b) {
+		sim = math.Max(sim, 0.9)
+	}
+	return sim
+}
+
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(vals ...int) int {
+	m := vals[0]
+	for _, v := range vals[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// nameOverlap is the share of names in a that have a close match in b.
+func nameOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	var found float64
+	for _, name := range a {
+		for _, other := range b {
+			if similarity(normalize(name), normalize(other)) >= 0.85 {
+				found++
+				break
+			}
+		}
+	}
+	return found / float64(len(a))
+}
+
+// durationSimilarity is 1 within 2% of the library runtime and drops to 0 at
+// a 20% difference.
+func durationSimilarity(lib, candidate time.Duration) float64 {
+	delta := math.Abs(float64(lib-candidate)) / float64(lib)
+	switch {
+	case delta <= 0.02:
+		return 1
+	case delta >= 0.2:
+		return 0
+	default:
+		return 1 - (delta-0.02)/0.18
+	}
+}
+
+// parseDuration understands hh:mm:ss, hh:mm, go durations like 10h5m and a
+// plain number of minutes.
+func parseDuration(s string) time.Duration {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+
+	if strings.Contains(s, ":") {
+		parts := strings.Split(s, ":")
+		var d time.Duration
+		units := []time.Duration{time.Hour, time.Minute, time.Second}
+		for i, p := range parts {
+			if i >= len(units) {
+				break
+			}
+			n, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return 0
+			}
+			d += time.Duration(n * float64(units[i]))
+		}
+		return d
+	}
+
+	if d, err := time.ParseDuration(strings.ReplaceAll(s, " ", "")); err == nil {
+		return d
+	}
+
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(n * float64(time.Minute))
+	}
+
+	return 0
+}
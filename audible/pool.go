@@ -0,0 +1,85 @@
+package audible
+
+import (
+	"context"
+	"sync"
+
+	"github.com/geziyor/geziyor/client"
+	"github.com/ohzqq/urbooks-core/bubbles"
+	"golang.org/x/time/rate"
+)
+
+// limiter is shared by the api client, cover downloads and the web scraper so
+// the requests per second setting covers everything sent to audible.
+var limiter = rate.NewLimiter(rate.Limit(clientOpts.RequestsPerSecond), 1)
+
+func SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	clientOpts.Concurrency = n
+}
+
+func SetRequestsPerSecond(rps float64) {
+	clientOpts.RequestsPerSecond = rps
+	switch {
+	case rps <= 0:
+		limiter.SetLimit(rate.Inf)
+	default:
+		limiter.SetLimit(rate.Limit(rps))
+	}
+}
+
+// runPool calls work for every index in [0, jobs) with at most
+// clientOpts.Concurrency calls in flight. Callers store results by index so
+// the order of the input is preserved.
+func runPool(ctx context.Context, jobs int, bar *bubbles.Progress, work func(ctx context.Context, i int) error) []error {
+	var (
+		errs = make([]error, jobs)
+		idx  = make(chan int)
+		wg   sync.WaitGroup
+	)
+
+	workers := clientOpts.Concurrency
+	if workers > jobs {
+		workers = jobs
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+				} else {
+					errs[i] = work(ctx, i)
+				}
+				if bar != nil {
+					bar.Inc(errs[i])
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < jobs; i++ {
+		idx <- i
+	}
+	close(idx)
+	wg.Wait()
+
+	return errs
+}
+
+type rateLimitMiddleware struct {
+	ctx context.Context
+}
+
+func (m rateLimitMiddleware) ProcessRequest(r *client.Request) {
+	if cache.has(cacheHtml, r.URL.String()) {
+		return
+	}
+	if err := limiter.Wait(m.ctx); err != nil {
+		r.Cancel()
+	}
+}
@@ -1,9 +1,8 @@
 package audible
 
 import (
-	"io"
-	"log"
-	"net/http"
+	"context"
+	"fmt"
 	"os"
 	"strings"
 
@@ -15,27 +14,17 @@ func getAsin(path string) string {
 	return paths[len(paths)-1]
 }
 
-func DownloadCover(name, u string) {
-	response, err := http.Get(u)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode != 200 {
-		log.Fatal(response.StatusCode)
+func DownloadCover(ctx context.Context, name, u string) error {
+	if u == "" {
+		return fmt.Errorf("no cover url for %v", name)
 	}
 
-	file, err := os.Create(slug.Make(name) + ".jpg")
+	img, err := fetch(ctx, audibleClient, cacheCover, u)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("cover download for %v failed: %w", name, err)
 	}
-	defer file.Close()
 
-	_, err = io.Copy(file, response.Body)
-	if err != nil {
-		log.Fatal(err)
-	}
+	return os.WriteFile(slug.Make(name)+".jpg", img, 0644)
 }
 
 var countryCodes = map[string]string{
@@ -1,16 +1,21 @@
 package audible
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/ohzqq/urbooks-core/book"
+	"github.com/ohzqq/urbooks-core/bubbles"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/geziyor/geziyor"
+	gcache "github.com/geziyor/geziyor/cache"
 	"github.com/geziyor/geziyor/client"
+	"github.com/geziyor/geziyor/middleware"
 )
 
 const audibleHost = "www.audible"
@@ -22,6 +27,11 @@ type WebScraper struct {
 	URLs        map[string]string
 	Books       []*book.Book
 	searchQuery url.Values
+	errs        []error
+	failed      map[string]error
+	ctx         context.Context
+	progress    *bubbles.Progress
+	mtx         sync.Mutex
 
 	AudibleURL string
 	Suffix     string
@@ -36,33 +46,142 @@ func newScraper() *WebScraper {
 			ConcurrentRequests: 1,
 			LogDisabled:        true,
 		},
+		ctx:         context.Background(),
 		URLs:        make(map[string]string),
 		searchQuery: make(url.Values),
 	}
 }
 
-func (a *WebScraper) getBook(u string) *book.Book {
-	books := a.scrapeUrls(u)
+func (a *WebScraper) getBook(u string) (*book.Book, error) {
+	books, err := a.scrapeUrls(u)
 	if len(books) > 0 {
-		return books[0]
+		return books[0], nil
 	}
-	return nil
+	if err == nil {
+		err = fmt.Errorf("no book found at %v", u)
+	}
+	return nil, err
+}
+
+// scrapeUrls scrapes all the urls with one crawler, the books are returned
+// in the same order as the urls. A failed url doesn't stop the others, the
+// failed asins are reported in a *BatchError.
+func (a *WebScraper) scrapeUrls(urls ...string) ([]*book.Book, error) {
+	var (
+		books = make([]*book.Book, len(urls))
+		idx   = make(map[string]int)
+	)
+	for i, u := range urls {
+		idx[u] = i
+	}
+
+	a.ScraperOpts.StartURLs = urls
+	a.ScraperOpts.ParseFunc = func(g *geziyor.Geziyor, r *client.Response) {
+		u := requestedURL(r.Request)
+		b, err := a.scrapeBook(r)
+
+		a.mtx.Lock()
+		if err != nil {
+			a.failed[u] = err
+		} else {
+			books[idx[u]] = b
+		}
+		a.mtx.Unlock()
+
+		if a.progress != nil {
+			a.progress.Inc(err)
+		}
+	}
+	a.start()
+
+	var (
+		batchErr = newBatchError(len(urls))
+		pending  = a.ctx.Err()
+	)
+	if pending == nil {
+		pending = fmt.Errorf("no response")
+	}
+	a.Books = nil
+	for i, b := range books {
+		switch {
+		case b != nil:
+			a.Books = append(a.Books, b)
+		case a.failed[urls[i]] != nil:
+			batchErr.add(urlAsin(urls[i]), a.failed[urls[i]])
+		default:
+			batchErr.add(urlAsin(urls[i]), pending)
+		}
+	}
+	return a.Books, batchErr.errOrNil()
+}
+
+func (a *WebScraper) start() {
+	a.errs = nil
+	a.failed = make(map[string]error)
+	a.ScraperOpts.ConcurrentRequests = clientOpts.Concurrency
+	a.ScraperOpts.RequestMiddlewares = []middleware.RequestProcessor{
+		rateLimitMiddleware{ctx: a.ctx},
+	}
+	a.ScraperOpts.Cache = htmlCache{Cache: cache}
+	a.ScraperOpts.CachePolicy = gcache.Dummy
+	a.ScraperOpts.Timeout = clientOpts.Timeout
+	a.ScraperOpts.RetryTimes = clientOpts.Retries
+	a.ScraperOpts.RetryHTTPCodes = []int{429, 500, 502, 503, 504, 522, 524, 408}
+	a.ScraperOpts.ErrorFunc = func(g *geziyor.Geziyor, r *client.Request, err error) {
+		a.mtx.Lock()
+		a.failed[requestedURL(r)] = err
+		a.mtx.Unlock()
+		if a.progress != nil {
+			a.progress.Inc(err)
+		}
+	}
+	a.ScraperOpts.StartRequestsFunc = func(g *geziyor.Geziyor) {
+		for _, u := range a.ScraperOpts.StartURLs {
+			req, err := client.NewRequest("GET", u, nil)
+			if err != nil {
+				a.mtx.Lock()
+				a.failed[u] = err
+				a.mtx.Unlock()
+				continue
+			}
+			req.Meta["url"] = u
+			g.Do(req, a.ScraperOpts.ParseFunc)
+		}
+	}
+	geziyor.NewGeziyor(a.ScraperOpts).Start()
+}
+
+// requestedURL is the start url of a request, its URL may have been
+// redirected.
+func requestedURL(r *client.Request) string {
+	if u, ok := r.Meta["url"].(string); ok {
+		return u
+	}
+	return r.URL.String()
 }
 
-func (a *WebScraper) scrapeUrls(urls ...string) []*book.Book {
-	for _, u := range urls {
-		a.ScraperOpts.StartURLs = []string{u}
-		a.ScraperOpts.ParseFunc = a.scrapeBook()
-		geziyor.NewGeziyor(a.ScraperOpts).Start()
+func (a *WebScraper) err() error {
+	var msgs []string
+	for _, u := range a.ScraperOpts.StartURLs {
+		if err, ok := a.failed[u]; ok {
+			msgs = append(msgs, fmt.Sprintf("%v: %v", u, err))
+		}
 	}
-	return a.Books
+	for _, err := range a.errs {
+		msgs = append(msgs, err.Error())
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("scraping failed:\n%v", strings.Join(msgs, "\n"))
 }
 
-func (a *WebScraper) getListURLs(aUrl string) []string {
+func (a *WebScraper) getListURLs(aUrl string) ([]string, error) {
 	var urls []string
-	//urls := make(map[string]string)
 	a.ScraperOpts.StartURLs = []string{aUrl}
 	a.ScraperOpts.ParseFunc = func(g *geziyor.Geziyor, r *client.Response) {
+		a.mtx.Lock()
+		defer a.mtx.Unlock()
 		metaList := r.HTMLDoc.Find("li.productListItem")
 		metaList.Each(func(_ int, s *goquery.Selection) {
 			link := s.Find("li.bc-list-item h3.bc-heading a")
@@ -74,7 +193,8 @@ func (a *WebScraper) getListURLs(aUrl string) []string {
 			if href != "" {
 				pd, err := url.Parse(href)
 				if err != nil {
-					log.Fatal(err)
+					a.errs = append(a.errs, err)
+					return
 				}
 				urls = append(urls, pd.Path)
 
@@ -83,63 +203,69 @@ func (a *WebScraper) getListURLs(aUrl string) []string {
 			}
 		})
 	}
-	geziyor.NewGeziyor(a.ScraperOpts).Start()
+	a.start()
 
-	return urls
+	return urls, a.err()
 }
 
-func (a *WebScraper) scrapeBook() func(g *geziyor.Geziyor, r *client.Response) {
-	return func(g *geziyor.Geziyor, r *client.Response) {
-		b := book.NewBook()
+func (a *WebScraper) scrapeBook(r *client.Response) (*book.Book, error) {
+	b := book.NewBook()
 
-		title := b.GetField("title")
-		t := strings.TrimSpace(r.HTMLDoc.Find("li.bc-list-item h1.bc-heading").Text())
-		title.SetMeta(t)
+	title := b.GetField("title")
+	t := strings.TrimSpace(r.HTMLDoc.Find("li.bc-list-item h1.bc-heading").Text())
+	title.SetMeta(t)
 
-		coverURL, _ := r.HTMLDoc.Find(".hero-content img.bc-pub-block").Attr("src")
-		b.GetField("cover").Item().Set("url", coverURL)
-
-		if f := b.GetField("authors"); f.IsNull() {
-			authors := f.Collection()
-			r.HTMLDoc.Find(".authorLabel a").Each(func(_ int, s *goquery.Selection) {
-				if text := s.Text(); text != "" {
-					authors.AddItem().Set("value", text)
-				}
-			})
-		}
+	coverURL, _ := r.HTMLDoc.Find(".hero-content img.bc-pub-block").Attr("src")
+	b.GetField("cover").Item().Set("url", coverURL)
 
-		b.AddField(book.NewCollection("#narrators"))
-		narrators := b.GetField("#narrators").SetIsNames().SetIsMultiple().Collection()
-		r.HTMLDoc.Find(".narratorLabel a").Each(func(_ int, s *goquery.Selection) {
+	if f := b.GetField("authors"); f.IsNull() {
+		authors := f.Collection()
+		r.HTMLDoc.Find(".authorLabel a").Each(func(_ int, s *goquery.Selection) {
 			if text := s.Text(); text != "" {
-				narrators.AddItem().Set("value", text)
+				authors.AddItem().Set("value", text)
 			}
 		})
+	}
 
-		seriesHtml := strings.TrimPrefix(strings.TrimSpace(r.HTMLDoc.Find(".seriesLabel").Text()), "Series:")
-		allSeries := regexp.MustCompile(`(\w+\s?){1,}, (Book \d+)`).FindAllString(seriesHtml, -1)
-		if len(allSeries) > 0 {
-			split := strings.Split(allSeries[0], ", Book ")
+	b.AddField(book.NewCollection("#narrators"))
+	narrators := b.GetField("#narrators").SetIsNames().SetIsMultiple().Collection()
+	r.HTMLDoc.Find(".narratorLabel a").Each(func(_ int, s *goquery.Selection) {
+		if text := s.Text(); text != "" {
+			narrators.AddItem().Set("value", text)
+		}
+	})
 
-			series := b.GetField("series").Item()
-			series.Set("value", split[0]).Set("position", split[1])
+	seriesHtml := strings.TrimPrefix(strings.TrimSpace(r.HTMLDoc.Find(".seriesLabel").Text()), "Series:")
+	allSeries := regexp.MustCompile(`(\w+\s?){1,}, (Book \d+)`).FindAllString(seriesHtml, -1)
+	if len(allSeries) > 0 {
+		split := strings.Split(allSeries[0], ", Book ")
 
-			position := b.GetField("position")
-			position.SetMeta(split[1])
-		}
+		series := b.GetField("series").Item()
+		series.Set("value", split[0]).Set("position", split[1])
 
-		tags := b.GetField("tags").Collection()
-		r.HTMLDoc.Find(".bc-chip-text").Each(func(_ int, s *goquery.Selection) {
-			tags.AddItem().Set("value", strings.TrimSpace(s.Text()))
-		})
+		position := b.GetField("position")
+		position.SetMeta(split[1])
+	}
 
-		description := b.GetField("description")
-		desc, err := r.HTMLDoc.Find(".productPublisherSummary span.bc-text").Html()
-		if err != nil {
-			log.Fatal(err)
-		}
-		description.SetMeta(desc)
+	tags := b.GetField("tags").Collection()
+	r.HTMLDoc.Find(".bc-chip-text").Each(func(_ int, s *goquery.Selection) {
+		tags.AddItem().Set("value", strings.TrimSpace(s.Text()))
+	})
+
+	description := b.GetField("description")
+	desc, err := r.HTMLDoc.Find(".productPublisherSummary span.bc-text").Html()
+	if err != nil {
+		return nil, err
+	}
+	description.SetMeta(desc)
+
+	return b, nil
+}
 
-		a.Books = append(a.Books, b)
+// urlAsin is the asin at the end of a book url's path.
+func urlAsin(u string) string {
+	if pu, err := url.Parse(u); err == nil {
+		return getAsin(pu.Path)
 	}
+	return u
 }
@@ -82,26 +82,14 @@ func (books *Books) UnmarshalJSON(r []byte) error {
 					field.Collection().AddItem().Set("value", name)
 					book.customColumns = append(book.customColumns, name)
 
-					meta := make(map[string]string)
+					var meta customMeta
 					err = json.Unmarshal(cdata["meta"], &meta)
 					if err != nil {
 						return fmt.Errorf("custom column parsing error: %v\n", err)
 					}
 
-					var col *Field
-					switch meta["is_multiple"] {
-					case "true":
-						col = book.AddField(NewCollection(name))
-					case "false":
-						col = book.AddField(NewColumn(name))
-					}
-					col.SetIsCustom().SetIsEditable()
-
+					col := book.AddField(newCustomField(name, meta))
 					col.SetMeta(cdata["data"])
-
-					if meta["is_names"] == "true" {
-						col.SetIsNames()
-					}
 				}
 			default:
 				field.SetMeta(value)
@@ -197,6 +185,31 @@ func (b Book) GetFile(f string) *Item {
 	return item
 }
 
+// GetIdentifier returns the value of the identifier with the scheme, calibre
+// stores identifiers as scheme:value.
+func (b Book) GetIdentifier(scheme string) string {
+	for _, i := range b.GetField("identifiers").Collection().EachItem() {
+		id := strings.SplitN(i.Get("value"), ":", 2)
+		if len(id) == 2 && strings.EqualFold(id[0], scheme) {
+			return id[1]
+		}
+	}
+	return ""
+}
+
+// CustomColumns returns the book's custom columns that have a value, sorted
+// by label.
+func (b Book) CustomColumns() []*Field {
+	var cols []*Field
+	for _, name := range b.customColumns {
+		if col := b.GetField(name); col != nil && !col.IsNull() {
+			cols = append(cols, col)
+		}
+	}
+	slices.SortFunc(cols, func(a, b *Field) bool { return a.Label() < b.Label() })
+	return cols
+}
+
 func (b Book) FilterValue() string {
 	var filter []string
 	for _, field := range []string{"title", "authors", "series"} {
@@ -362,12 +375,28 @@ func (i *Item) ParseMeta(f *Field) Meta {
 	return i
 }
 
+// UnmarshalJSON reads an item's values, numbers and booleans, like a custom
+// series' position, are kept as strings.
 func (i *Item) UnmarshalJSON(b []byte) error {
 	if len(b) > 0 {
-		if err := json.Unmarshal(b, &i.data); err != nil {
+		var data map[string]any
+		if err := json.Unmarshal(b, &data); err != nil {
 			fmt.Printf("collection failed: %v\n", err)
 			return err
 		}
+		if i.data == nil {
+			i.data = make(map[string]string)
+		}
+		for k, v := range data {
+			switch val := v.(type) {
+			case string:
+				i.data[k] = val
+			case float64:
+				i.data[k] = strconv.FormatFloat(val, 'f', -1, 64)
+			case bool:
+				i.data[k] = strconv.FormatBool(val)
+			}
+		}
 	}
 	return nil
 }
@@ -0,0 +1,84 @@
+package book
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/gosimple/slug"
+)
+
+func (b *Book) AddChapter(title string, start, end int) *Item {
+	return b.GetField("chapters").Collection().AddItem().
+		Set("value", title).
+		Set("start", strconv.Itoa(start)).
+		Set("end", strconv.Itoa(end))
+}
+
+func (b *Book) Chapters() []*Item {
+	return b.GetField("chapters").Collection().EachItem()
+}
+
+type audibleChapter struct {
+	Title    string           `json:"title"`
+	Length   int              `json:"length_ms"`
+	Start    int              `json:"start_offset_ms"`
+	Chapters []audibleChapter `json:"chapters"`
+}
+
+func (b *Book) setAudibleChapters(d []byte) error {
+	var info struct {
+		Chapters []audibleChapter `json:"chapters"`
+	}
+	err := json.Unmarshal(d, &info)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal audible chapters %v\n", err)
+	}
+	b.addAudibleChapters(info.Chapters)
+	return nil
+}
+
+func (b *Book) addAudibleChapters(chapters []audibleChapter) {
+	for _, ch := range chapters {
+		b.AddChapter(ch.Title, ch.Start, ch.Start+ch.Length)
+		if len(ch.Chapters) > 0 {
+			b.addAudibleChapters(ch.Chapters)
+		}
+	}
+}
+
+func ToCue(b *Book) *bytes.Buffer {
+	var buf bytes.Buffer
+
+	audio := b.GetFile("audio").Get("value")
+	if audio == "" {
+		audio = slug.Make(b.GetMeta("title")) + ".m4b"
+	}
+
+	fmt.Fprintf(&buf, "TITLE %q\n", b.GetTitleAndSeries())
+	if authors := b.GetField("authors"); !authors.IsNull() {
+		fmt.Fprintf(&buf, "PERFORMER %q\n", authors.String())
+	}
+	fmt.Fprintf(&buf, "FILE %q WAVE\n", audio)
+
+	for i, ch := range b.Chapters() {
+		fmt.Fprintf(&buf, "  TRACK %02d AUDIO\n", i+1)
+		fmt.Fprintf(&buf, "    TITLE %q\n", ch.Get("value"))
+		fmt.Fprintf(&buf, "    INDEX 01 %s\n", cueTimestamp(ch.Get("start")))
+	}
+
+	return &buf
+}
+
+// cue sheets index in mm:ss:ff, with 75 frames to the second
+func cueTimestamp(ms string) string {
+	t, err := strconv.Atoi(ms)
+	if err != nil {
+		t = 0
+	}
+	min := t / 60000
+	sec := t % 60000 / 1000
+	frames := t % 1000 * 75 / 1000
+	return fmt.Sprintf("%02d:%02d:%02d", min, sec, frames)
+}
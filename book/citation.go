@@ -0,0 +1,305 @@
+package book
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosimple/slug"
+)
+
+// Cite renders the books as one bibtex, ris or csl-json file. Books with the
+// same citation key get a letter suffix, in the order given.
+func Cite(format string, books ...*Book) (*bytes.Buffer, error) {
+	switch format {
+	case "bibtex":
+		return ToBibtex(books...), nil
+	case "ris":
+		return ToRIS(books...), nil
+	case "csl-json":
+		return ToCslJSON(books...), nil
+	}
+	return nil, fmt.Errorf("%v is not a citation format, use bibtex, ris or csl-json\n", format)
+}
+
+// CitationKey is the first author's family name, the year published and the
+// first word of the title that isn't an article, eg doe2019dragons.
+func (b *Book) CitationKey() string {
+	key := "anon"
+	if names := b.citationAuthors(); len(names) > 0 {
+		key = names[0].Family
+	}
+
+	if t, ok := b.publishedDate(); ok {
+		key += strconv.Itoa(t.Year())
+	}
+
+	for _, word := range strings.Fields(b.GetMeta("title")) {
+		w := strings.ToLower(word)
+		if w == "a" || w == "an" || w == "the" {
+			continue
+		}
+		key += word
+		break
+	}
+
+	return strings.ReplaceAll(slug.Make(key), "-", "")
+}
+
+// citationKeys makes duplicate keys unique with a suffix, a to z then aa,
+// ab and so on, skipping any that another book already has.
+func citationKeys(books []*Book) []string {
+	var (
+		keys  []string
+		count = make(map[string]int)
+		used  = make(map[string]bool)
+	)
+	for _, b := range books {
+		key := b.CitationKey()
+		count[key]++
+		used[key] = true
+	}
+	next := make(map[string]int)
+	for _, b := range books {
+		key := b.CitationKey()
+		if count[key] > 1 {
+			base := key
+			for {
+				key = base + keySuffix(next[base])
+				next[base]++
+				if !used[key] {
+					break
+				}
+			}
+			used[key] = true
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func keySuffix(n int) string {
+	var s []rune
+	for n++; n > 0; n = (n - 1) / 26 {
+		s = append([]rune{rune('a' + (n-1)%26)}, s...)
+	}
+	return string(s)
+}
+
+type citationName struct {
+	Family string `json:"family,omitempty"`
+	Given  string `json:"given,omitempty"`
+}
+
+func (n citationName) String() string {
+	if n.Given == "" {
+		return n.Family
+	}
+	return n.Family + ", " + n.Given
+}
+
+// citationAuthors splits author names into family and given names, names
+// already written as family, given are kept as is.
+func (b *Book) citationAuthors() []citationName {
+	var names []citationName
+	f := b.GetField("authors")
+	if f == nil || f.IsNull() {
+		return names
+	}
+	for _, name := range f.Collection().StringSlice() {
+		if family, given, ok := strings.Cut(name, ","); ok {
+			names = append(names, citationName{Family: strings.TrimSpace(family), Given: strings.TrimSpace(given)})
+			continue
+		}
+		parts := strings.Fields(name)
+		if len(parts) == 0 {
+			continue
+		}
+		names = append(names, citationName{
+			Family: parts[len(parts)-1],
+			Given:  strings.Join(parts[:len(parts)-1], " "),
+		})
+	}
+	return names
+}
+
+// publishedDate ignores calibre's placeholder dates, which are year 101.
+func (b *Book) publishedDate() (time.Time, bool) {
+	t, ok := parseDate(b.GetMeta("published"))
+	if !ok || t.Year() < 1000 {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func (b *Book) citationPosition() string {
+	if b.GetMeta("series") == "" {
+		return ""
+	}
+	if pos := b.GetField("series").Item().Get("position"); pos != "" {
+		return pos
+	}
+	return b.GetMeta("position")
+}
+
+var htmlTags = regexp.MustCompile(`<[^>]+>`)
+
+// citationAbstract is the description as plain text.
+func (b *Book) citationAbstract() string {
+	d := htmlTags.ReplaceAllString(b.GetMeta("description"), " ")
+	return strings.Join(strings.Fields(html.UnescapeString(d)), " ")
+}
+
+func (b *Book) citationKeywords() []string {
+	if f := b.GetField("tags"); f != nil && !f.IsNull() {
+		return f.Collection().StringSlice()
+	}
+	return nil
+}
+
+var bibEscaper = strings.NewReplacer(
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`{`, `\{`,
+	`}`, `\}`,
+)
+
+func ToBibtex(books ...*Book) *bytes.Buffer {
+	var buf bytes.Buffer
+	for i, key := range citationKeys(books) {
+		b := books[i]
+
+		var authors []string
+		for _, n := range b.citationAuthors() {
+			authors = append(authors, n.String())
+		}
+
+		fields := [][2]string{
+			{"author", strings.Join(authors, " and ")},
+			{"title", b.GetMeta("title")},
+			{"series", b.GetMeta("series")},
+			{"number", b.citationPosition()},
+		}
+		if t, ok := b.publishedDate(); ok {
+			fields = append(fields,
+				[2]string{"year", strconv.Itoa(t.Year())},
+				[2]string{"month", strings.ToLower(t.Format("Jan"))},
+			)
+		}
+		fields = append(fields,
+			[2]string{"publisher", b.GetMeta("publisher")},
+			[2]string{"isbn", b.GetIdentifier("isbn")},
+			[2]string{"doi", b.GetIdentifier("doi")},
+			[2]string{"keywords", strings.Join(b.citationKeywords(), ", ")},
+			[2]string{"abstract", b.citationAbstract()},
+		)
+
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "@book{%s,\n", key)
+		for _, f := range fields {
+			if f[1] == "" {
+				continue
+			}
+			fmt.Fprintf(&buf, "  %s = {%s},\n", f[0], bibEscaper.Replace(f[1]))
+		}
+		buf.WriteString("}\n")
+	}
+	return &buf
+}
+
+func ToRIS(books ...*Book) *bytes.Buffer {
+	var buf bytes.Buffer
+	tag := func(t, v string) {
+		if v = strings.Join(strings.Fields(v), " "); v != "" {
+			fmt.Fprintf(&buf, "%s  - %s\n", t, v)
+		}
+	}
+
+	for i, key := range citationKeys(books) {
+		b := books[i]
+		tag("TY", "BOOK")
+		tag("ID", key)
+		for _, n := range b.citationAuthors() {
+			tag("AU", n.String())
+		}
+		tag("TI", b.GetMeta("title"))
+		tag("T3", b.GetMeta("series"))
+		tag("VL", b.citationPosition())
+		if t, ok := b.publishedDate(); ok {
+			tag("PY", strconv.Itoa(t.Year()))
+			tag("DA", t.Format("2006/01/02/"))
+		}
+		tag("PB", b.GetMeta("publisher"))
+		tag("SN", b.GetIdentifier("isbn"))
+		tag("DO", b.GetIdentifier("doi"))
+		for _, kw := range b.citationKeywords() {
+			tag("KW", kw)
+		}
+		tag("AB", b.citationAbstract())
+		buf.WriteString("ER  - \n")
+	}
+	return &buf
+}
+
+type cslItem struct {
+	ID               string         `json:"id"`
+	Type             string         `json:"type"`
+	Title            string         `json:"title,omitempty"`
+	Author           []citationName `json:"author,omitempty"`
+	Issued           *cslDate       `json:"issued,omitempty"`
+	Publisher        string         `json:"publisher,omitempty"`
+	CollectionTitle  string         `json:"collection-title,omitempty"`
+	CollectionNumber string         `json:"collection-number,omitempty"`
+	ISBN             string         `json:"ISBN,omitempty"`
+	DOI              string         `json:"DOI,omitempty"`
+	Keyword          string         `json:"keyword,omitempty"`
+	Abstract         string         `json:"abstract,omitempty"`
+}
+
+type cslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+func ToCslJSON(books ...*Book) *bytes.Buffer {
+	items := []cslItem{}
+	for i, key := range citationKeys(books) {
+		b := books[i]
+		item := cslItem{
+			ID:               key,
+			Type:             "book",
+			Title:            b.GetMeta("title"),
+			Author:           b.citationAuthors(),
+			Publisher:        b.GetMeta("publisher"),
+			CollectionTitle:  b.GetMeta("series"),
+			CollectionNumber: b.citationPosition(),
+			ISBN:             b.GetIdentifier("isbn"),
+			DOI:              b.GetIdentifier("doi"),
+			Keyword:          strings.Join(b.citationKeywords(), ", "),
+			Abstract:         b.citationAbstract(),
+		}
+		if t, ok := b.publishedDate(); ok {
+			item.Issued = &cslDate{DateParts: [][]int{{t.Year(), int(t.Month()), t.Day()}}}
+		}
+		items = append(items, item)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(items); err != nil {
+		log.Fatal(err)
+	}
+	return &buf
+}
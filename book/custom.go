@@ -0,0 +1,369 @@
+package book
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// customMeta is the meta of a custom column in an api response.
+type customMeta struct {
+	IsNames     string   `json:"is_names"`
+	IsMultiple  string   `json:"is_multiple"`
+	Normalized  string   `json:"normalized"`
+	Datatype    string   `json:"datatype"`
+	InterpretAs string   `json:"interpret_as"`
+	EnumValues  []string `json:"enum_values"`
+	Template    string   `json:"template"`
+}
+
+// newCustomField returns a field with the Meta for the custom column's
+// calibre datatype.
+func newCustomField(name string, m customMeta) *Field {
+	var field *Field
+	switch {
+	case m.IsMultiple == "true":
+		field = NewCollection(name)
+	case m.Datatype == "bool":
+		field = NewField(name)
+		field.Meta = NewMetaBool()
+	case m.Datatype == "int", m.Datatype == "float", m.Datatype == "rating":
+		field = NewField(name)
+		field.Meta = NewMetaNumber(m.Datatype)
+	case m.Datatype == "datetime":
+		field = NewField(name)
+		field.Meta = NewMetaDate()
+	case m.Datatype == "enumeration":
+		field = NewField(name)
+		field.Meta = NewMetaEnum(m.EnumValues...)
+	case m.Datatype == "comments":
+		field = NewField(name)
+		field.Meta = NewMetaComments(m.InterpretAs)
+	case m.Datatype == "composite":
+		field = NewField(name)
+		field.Meta = NewMetaComposite(m.Template)
+	case m.Datatype == "series", m.Normalized == "true":
+		field = NewItem(name)
+	default:
+		field = NewColumn(name)
+	}
+	field.Datatype = m.Datatype
+	field.SetIsCustom()
+	if m.Datatype != "composite" {
+		field.SetIsEditable()
+	}
+	if m.IsNames == "true" {
+		field.SetIsNames()
+	}
+	return field
+}
+
+// Validate checks that value can be written to the field.
+func (f *Field) Validate(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	switch meta := f.Meta.(type) {
+	case *Bool:
+		if _, err := parseBool(value); err != nil {
+			return fmt.Errorf("%v: %v", f.Label(), err)
+		}
+	case *Number:
+		if _, err := meta.parse(value); err != nil {
+			return fmt.Errorf("%v: %v", f.Label(), err)
+		}
+	case *Date:
+		if _, ok := parseDate(value); !ok {
+			return fmt.Errorf("%v: %v is not a date, use yyyy-mm-dd", f.Label(), value)
+		}
+	case *Enum:
+		if len(meta.values) > 0 && !slices.Contains(meta.values, value) {
+			return fmt.Errorf("%v: %v is not one of %v", f.Label(), value, strings.Join(meta.values, ", "))
+		}
+	case *Composite:
+		return fmt.Errorf("%v is computed from a template and can't be set", f.Label())
+	}
+	return nil
+}
+
+// Bool is a yes or no custom column, it's null when it isn't set.
+type Bool struct {
+	data *bool
+}
+
+func NewMetaBool() *Bool {
+	return &Bool{}
+}
+
+func (b *Bool) Value() bool {
+	return b.data != nil && *b.data
+}
+
+func (b *Bool) String(f *Field) string {
+	switch {
+	case b.data == nil:
+		return ""
+	case *b.data:
+		return "Yes"
+	default:
+		return "No"
+	}
+}
+
+func (b *Bool) URL(f *Field) string {
+	return ""
+}
+
+func (b *Bool) IsNull() bool {
+	return b.data == nil
+}
+
+func (b *Bool) RawData() interface{} {
+	if b.data == nil {
+		return nil
+	}
+	return *b.data
+}
+
+func (b *Bool) ParseMeta(f *Field) Meta {
+	b.data = nil
+	switch d := f.data.(type) {
+	case bool:
+		b.data = &d
+	case string:
+		if v, err := parseBool(d); err == nil && strings.TrimSpace(d) != "" {
+			b.data = &v
+		}
+	case json.RawMessage:
+		if len(d) > 0 {
+			if err := json.Unmarshal(d, &b.data); err != nil {
+				fmt.Printf("%v failed: %v\n", f.Label(), err)
+			}
+		}
+	}
+	return b
+}
+
+func parseBool(v string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "yes", "y", "true", "1":
+		return true, nil
+	case "no", "n", "false", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("%v is not yes or no", v)
+}
+
+// Number is an int, float or rating custom column. Ratings are stored like
+// calibre's, from 0 to 10, two per star.
+type Number struct {
+	data     *float64
+	datatype string
+}
+
+func NewMetaNumber(datatype string) *Number {
+	return &Number{datatype: datatype}
+}
+
+func (n *Number) Value() float64 {
+	if n.data == nil {
+		return 0
+	}
+	return *n.data
+}
+
+func (n *Number) String(f *Field) string {
+	if n.data == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*n.data, 'f', -1, 64)
+}
+
+func (n *Number) URL(f *Field) string {
+	return ""
+}
+
+func (n *Number) IsNull() bool {
+	return n.data == nil
+}
+
+func (n *Number) RawData() interface{} {
+	switch {
+	case n.data == nil:
+		return nil
+	case n.datatype == "float":
+		return *n.data
+	default:
+		return int64(*n.data)
+	}
+}
+
+func (n *Number) ParseMeta(f *Field) Meta {
+	n.data = nil
+	switch d := f.data.(type) {
+	case float64:
+		n.data = &d
+	case int:
+		v := float64(d)
+		n.data = &v
+	case string:
+		if v, err := n.parse(d); err == nil && strings.TrimSpace(d) != "" {
+			n.data = &v
+		}
+	case json.RawMessage:
+		if len(d) > 0 {
+			if err := json.Unmarshal(d, &n.data); err != nil {
+				fmt.Printf("%v failed: %v\n", f.Label(), err)
+			}
+		}
+	}
+	return n
+}
+
+func (n *Number) parse(v string) (float64, error) {
+	num, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("%v is not a number", v)
+	case n.datatype == "int" && num != math.Trunc(num):
+		return 0, fmt.Errorf("%v is not a whole number", v)
+	case n.datatype == "rating" && (num < 0 || num > 10):
+		return 0, fmt.Errorf("rating is between 0 and 10")
+	}
+	return num, nil
+}
+
+// Date is a datetime custom column.
+type Date struct {
+	data time.Time
+}
+
+func NewMetaDate() *Date {
+	return &Date{}
+}
+
+func (d *Date) Time() time.Time {
+	return d.data
+}
+
+func (d *Date) String(f *Field) string {
+	if d.data.IsZero() {
+		return ""
+	}
+	return d.data.Format("2006-01-02")
+}
+
+func (d *Date) URL(f *Field) string {
+	return ""
+}
+
+func (d *Date) IsNull() bool {
+	return d.data.IsZero()
+}
+
+func (d *Date) RawData() interface{} {
+	if d.data.IsZero() {
+		return nil
+	}
+	return d.data.Format("2006-01-02")
+}
+
+func (d *Date) ParseMeta(f *Field) Meta {
+	d.data = time.Time{}
+	var val string
+	switch data := f.data.(type) {
+	case time.Time:
+		d.data = data
+		return d
+	case string:
+		val = data
+	case json.RawMessage:
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &val); err != nil {
+				fmt.Printf("%v failed: %v\n", f.Label(), err)
+			}
+		}
+	}
+	if t, ok := parseDate(strings.TrimSpace(val)); ok {
+		d.data = t
+	}
+	return d
+}
+
+// Enum is an enumeration custom column, its value is one of a fixed list.
+type Enum struct {
+	*Item
+	values []string
+}
+
+func NewMetaEnum(values ...string) *Enum {
+	return &Enum{Item: NewMetaItem(), values: values}
+}
+
+// Values are the values the column can be set to.
+func (e *Enum) Values() []string {
+	return e.values
+}
+
+func (e *Enum) ParseMeta(f *Field) Meta {
+	switch d := f.data.(type) {
+	case string:
+		e.Item = NewMetaItem()
+		if d = strings.TrimSpace(d); d != "" {
+			e.Set("value", d)
+		}
+	default:
+		e.Item.ParseMeta(f)
+	}
+	return e
+}
+
+// Comments is a long text custom column, InterpretAs is how calibre displays
+// it: html, markdown, long-text or short-text.
+type Comments struct {
+	*Column
+	interpretAs string
+}
+
+func NewMetaComments(interpretAs string) *Comments {
+	return &Comments{Column: NewMetaColumn(), interpretAs: interpretAs}
+}
+
+func (c *Comments) InterpretAs() string {
+	if c.interpretAs == "" {
+		return "html"
+	}
+	return c.interpretAs
+}
+
+func (c *Comments) ParseMeta(f *Field) Meta {
+	c.Column.ParseMeta(f)
+	return c
+}
+
+// Composite is a custom column built from other fields with a calibre
+// template, it can't be edited.
+type Composite struct {
+	*Column
+	template string
+}
+
+func NewMetaComposite(template string) *Composite {
+	return &Composite{Column: NewMetaColumn(), template: template}
+}
+
+func (c *Composite) Template() string {
+	return c.template
+}
+
+func (c *Composite) ParseMeta(f *Field) Meta {
+	c.Column.ParseMeta(f)
+	return c
+}
@@ -0,0 +1,202 @@
+package book
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func customField(datatype string) *Field {
+	m := customMeta{Datatype: datatype}
+	switch datatype {
+	case "enumeration":
+		m.Normalized = "true"
+		m.EnumValues = []string{"Mystery", "Romance", "Horror"}
+	case "series":
+		m.Normalized = "true"
+	case "comments":
+		m.InterpretAs = "markdown"
+	case "composite":
+		m.Template = "{title} by {authors}"
+	}
+	return newCustomField("#"+datatype, m)
+}
+
+func TestNewCustomField(t *testing.T) {
+	for datatype, want := range map[string]Meta{
+		"bool":        &Bool{},
+		"int":         &Number{},
+		"float":       &Number{},
+		"rating":      &Number{},
+		"datetime":    &Date{},
+		"enumeration": &Enum{},
+		"comments":    &Comments{},
+		"composite":   &Composite{},
+		"series":      &Item{},
+		"text":        &Column{},
+	} {
+		f := customField(datatype)
+		if reflect.TypeOf(f.Meta) != reflect.TypeOf(want) {
+			t.Errorf("%v is a %T, want %T", datatype, f.Meta, want)
+		}
+		if !f.IsCustom || f.Datatype != datatype {
+			t.Errorf("%v: custom %v, datatype %v", datatype, f.IsCustom, f.Datatype)
+		}
+		if f.IsEditable == (datatype == "composite") {
+			t.Errorf("%v: editable is %v", datatype, f.IsEditable)
+		}
+	}
+
+	names := newCustomField("#narrators", customMeta{Datatype: "text", IsMultiple: "true", IsNames: "true"})
+	if !names.IsCollection() || !names.IsNames {
+		t.Errorf("multiple names are a %T, names %v", names.Meta, names.IsNames)
+	}
+	if c := customField("comments").Meta.(*Comments); c.InterpretAs() != "markdown" {
+		t.Errorf("comments are interpreted as %v", c.InterpretAs())
+	}
+	if c := newCustomField("#notes", customMeta{Datatype: "comments"}).Meta.(*Comments); c.InterpretAs() != "html" {
+		t.Errorf("comments are interpreted as %v by default, want html", c.InterpretAs())
+	}
+}
+
+func TestCustomBool(t *testing.T) {
+	for _, test := range []struct {
+		data any
+		null bool
+		want string
+	}{
+		{json.RawMessage("null"), true, ""},
+		{json.RawMessage(""), true, ""},
+		{json.RawMessage("false"), false, "No"},
+		{json.RawMessage("true"), false, "Yes"},
+		{"", true, ""},
+		{"no", false, "No"},
+		{"Yes", false, "Yes"},
+		{false, false, "No"},
+	} {
+		f := customField("bool").SetMeta(test.data)
+		if f.IsNull() != test.null || f.String() != test.want {
+			t.Errorf("%s: null %v %q, want null %v %q", test.data, f.IsNull(), f.String(), test.null, test.want)
+		}
+	}
+}
+
+func TestCustomNumber(t *testing.T) {
+	for _, test := range []struct {
+		datatype string
+		data     any
+		want     string
+		raw      any
+	}{
+		{"int", json.RawMessage("148"), "148", int64(148)},
+		{"int", "148", "148", int64(148)},
+		{"float", json.RawMessage("0.5"), "0.5", 0.5},
+		{"float", " 2.25 ", "2.25", 2.25},
+		{"rating", json.RawMessage("8"), "8", int64(8)},
+		{"rating", "11", "", nil},
+		{"float", "cheap", "", nil},
+		{"int", json.RawMessage("null"), "", nil},
+	} {
+		f := customField(test.datatype).SetMeta(test.data)
+		if f.String() != test.want || f.RawData() != test.raw {
+			t.Errorf("%v %s: got %q %#v, want %q %#v", test.datatype, test.data, f.String(), f.RawData(), test.want, test.raw)
+		}
+	}
+}
+
+func TestCustomValidate(t *testing.T) {
+	for _, test := range []struct {
+		datatype string
+		value    string
+		ok       bool
+	}{
+		{"bool", "yes", true},
+		{"bool", "N", true},
+		{"bool", "maybe", false},
+		{"int", "3", true},
+		{"int", "3.5", false},
+		{"int", "three", false},
+		{"float", "3.5", true},
+		{"float", "1e3", true},
+		{"float", "3,5", false},
+		{"rating", "10", true},
+		{"rating", "0", true},
+		{"rating", "11", false},
+		{"rating", "-2", false},
+		{"datetime", "2021-05-09", true},
+		{"datetime", "May", false},
+		{"enumeration", "Horror", true},
+		{"enumeration", "horror", false},
+		{"enumeration", "Comedy", false},
+		{"composite", "Book by Someone", false},
+		{"text", "anything at all", true},
+		{"series", "Side Tales", true},
+		{"int", " ", true},
+		{"composite", "", true},
+	} {
+		err := customField(test.datatype).Validate(test.value)
+		if (err == nil) != test.ok {
+			t.Errorf("%v %q: got error %v", test.datatype, test.value, err)
+		}
+	}
+}
+
+// TestCustomJSON reads custom columns like the api writes them and writes
+// their values back.
+func TestCustomJSON(t *testing.T) {
+	for _, test := range []struct {
+		datatype string
+		data     string
+		want     string
+	}{
+		{"bool", `false`, "No"},
+		{"bool", `null`, ""},
+		{"int", `148`, "148"},
+		{"float", `0.5`, "0.5"},
+		{"rating", `10`, "10"},
+		{"datetime", `"2021-05-09"`, "2021-05-09"},
+		{"comments", `"*note* 4"`, "*note* 4"},
+		{"composite", `"Book 004 by anne bell"`, "Book 004 by anne bell"},
+		{"text", `"10:05:00"`, "10:05:00"},
+		{"enumeration", `{"value":"Horror","id":"2","uri":"genre/2"}`, "Horror"},
+		{"series", `{"value":"Side Tales","id":"1","uri":"subseries/1","position":"1.5"}`, "Side Tales"},
+	} {
+		f := customField(test.datatype)
+		meta := customMeta{
+			Datatype:    f.Datatype,
+			Normalized:  fmt.Sprint(test.datatype == "enumeration" || test.datatype == "series"),
+			InterpretAs: "markdown",
+		}
+		cols := map[string]map[string]any{
+			"#" + test.datatype: {"meta": meta, "data": json.RawMessage(test.data)},
+		}
+		d, err := json.Marshal([]map[string]any{{"customColumns": cols}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var books Books
+		if err := json.Unmarshal(d, &books); err != nil {
+			t.Fatalf("%v: %v", test.datatype, err)
+		}
+		got := books.EachBook()[0].GetField("#" + test.datatype)
+		if reflect.TypeOf(got.Meta) != reflect.TypeOf(f.Meta) {
+			t.Errorf("%v: read as %T, want %T", test.datatype, got.Meta, f.Meta)
+		}
+		if got.String() != test.want {
+			t.Errorf("%v: read %q, want %q", test.datatype, got.String(), test.want)
+		}
+
+		raw, err := json.Marshal(got.RawData())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var a, b any
+		json.Unmarshal(raw, &a)
+		json.Unmarshal([]byte(test.data), &b)
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("%v: wrote %s, want %s", test.datatype, raw, test.data)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package book
+
+import (
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// FieldDiff describes how a field differs between a library book and a
+// remote one. Added and Removed are only set for collections.
+type FieldDiff struct {
+	Field      string
+	Library    string
+	Remote     string
+	IsMultiple bool
+	Added      []string
+	Removed    []string
+}
+
+// Diff compares the editable fields, including custom columns, of the
+// library book a with the remote book b. Only fields that differ are
+// returned, sorted by name.
+func Diff(a, b *Book) []FieldDiff {
+	var diffs []FieldDiff
+	for _, name := range diffFields(a, b) {
+		local := a.GetField(name)
+		remote := b.GetField(name)
+
+		d := FieldDiff{Field: name}
+		if local != nil {
+			d.Library = local.String()
+		}
+		if remote != nil {
+			d.Remote = remote.String()
+		}
+
+		if isCollection(local) || isCollection(remote) {
+			// collections are sets, the same values in another order
+			// aren't a difference
+			lv, rv := fieldValues(local), fieldValues(remote)
+			d.IsMultiple = true
+			d.Added = missing(rv, lv)
+			d.Removed = missing(lv, rv)
+			if len(d.Added) > 0 || len(d.Removed) > 0 {
+				diffs = append(diffs, d)
+			}
+			continue
+		}
+
+		if d.Library != d.Remote {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+func diffFields(books ...*Book) []string {
+	var names []string
+	for _, b := range books {
+		for name, f := range b.EachField() {
+			if !f.IsEditable || name == "id" || name == "added" {
+				continue
+			}
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func isCollection(f *Field) bool {
+	return f != nil && f.IsCollection()
+}
+
+func fieldValues(f *Field) []string {
+	if f == nil || f.IsNull() {
+		return nil
+	}
+	if f.IsCollection() {
+		return f.Collection().StringSlice()
+	}
+	return []string{f.String()}
+}
+
+func missing(vals, from []string) []string {
+	var m []string
+	for _, v := range vals {
+		if !slices.Contains(from, v) {
+			m = append(m, v)
+		}
+	}
+	return m
+}
+
+const (
+	PreferLibrary = "library"
+	PreferRemote  = "remote"
+	Union         = "union"
+)
+
+// MergePolicy decides which book a field is taken from when merging. Fields
+// without an override use the default, tags and identifiers are unioned.
+type MergePolicy struct {
+	Default string
+	Fields  map[string]string
+}
+
+func NewMergePolicy(def string) *MergePolicy {
+	return &MergePolicy{
+		Default: def,
+		Fields: map[string]string{
+			"tags":        Union,
+			"identifiers": Union,
+		},
+	}
+}
+
+func (p *MergePolicy) Set(field, strategy string) *MergePolicy {
+	p.Fields[field] = strategy
+	return p
+}
+
+func (p *MergePolicy) Get(field string) string {
+	if s, ok := p.Fields[field]; ok {
+		return s
+	}
+	return p.Default
+}
+
+// Merge returns a copy of the library book with the remote fields merged in
+// according to the policy. Null fields never replace ones with a value.
+func Merge(local, remote *Book, p *MergePolicy) *Book {
+	merged := local.Copy()
+
+	for _, name := range diffFields(local, remote) {
+		lf := local.GetField(name)
+		rf := remote.GetField(name)
+		if rf == nil || rf.IsNull() {
+			continue
+		}
+		if lf == nil || lf.IsNull() {
+			merged.AddField(rf.copy())
+			continue
+		}
+
+		switch p.Get(name) {
+		case PreferRemote:
+			merged.AddField(mergeMeta(lf, rf.Meta))
+		case Union:
+			if lf.IsCollection() && rf.IsCollection() {
+				merged.AddField(mergeMeta(lf, union(name, lf.Collection(), rf.Collection())))
+			}
+		}
+	}
+
+	return merged
+}
+
+// mergeMeta keeps the library field's settings with the remote field's
+// meta.
+func mergeMeta(f *Field, meta Meta) *Field {
+	field := f.copy()
+	field.Meta = copyMeta(meta)
+	return field
+}
+
+// union keeps the order of the library collection, identifiers are unioned
+// by scheme so a book only ever has one of each.
+func union(name string, local, remote *Collection) *Collection {
+	key := func(i *Item) string {
+		v := i.Get("value")
+		if name == "identifiers" {
+			return strings.SplitN(v, ":", 2)[0]
+		}
+		return strings.ToLower(v)
+	}
+
+	c := copyMeta(local).(*Collection)
+	var seen []string
+	for _, i := range c.EachItem() {
+		seen = append(seen, key(i))
+	}
+	for _, i := range remote.EachItem() {
+		if k := key(i); !slices.Contains(seen, k) {
+			seen = append(seen, k)
+			c.data = append(c.data, copyItem(i))
+		}
+	}
+	return c
+}
+
+// Copy returns a deep copy of the book.
+func (b *Book) Copy() *Book {
+	book := NewBook()
+	book.lib = b.lib
+	book.customColumns = append(book.customColumns, b.customColumns...)
+	book.displayFields = b.displayFields
+	for _, f := range b.EachField() {
+		book.AddField(f.copy())
+	}
+	return book
+}
+
+func (f *Field) copy() *Field {
+	field := *f
+	field.Meta = copyMeta(f.Meta)
+	return &field
+}
+
+func copyMeta(m Meta) Meta {
+	switch meta := m.(type) {
+	case *Collection:
+		c := NewMetaCollection()
+		for _, i := range meta.EachItem() {
+			c.data = append(c.data, copyItem(i))
+		}
+		return c
+	case *Item:
+		return copyItem(meta)
+	case *Column:
+		return NewMetaColumn().Set(meta.data)
+	case *Bool:
+		b := *meta
+		if meta.data != nil {
+			v := *meta.data
+			b.data = &v
+		}
+		return &b
+	case *Number:
+		n := *meta
+		if meta.data != nil {
+			v := *meta.data
+			n.data = &v
+		}
+		return &n
+	case *Date:
+		d := *meta
+		return &d
+	case *Enum:
+		return &Enum{Item: copyItem(meta.Item), values: meta.values}
+	case *Comments:
+		return &Comments{Column: NewMetaColumn().Set(meta.data), interpretAs: meta.interpretAs}
+	case *Composite:
+		return &Composite{Column: NewMetaColumn().Set(meta.data), template: meta.template}
+	}
+	return m
+}
+
+func copyItem(i *Item) *Item {
+	item := NewMetaItem()
+	for k, v := range i.data {
+		item.Set(k, v)
+	}
+	return item
+}
@@ -33,6 +33,7 @@ type Field struct {
 	Data         []byte `json:"-"`
 	Meta         Meta   `json:"-"`
 	CalibreLabel string `json:"label"`
+	Datatype     string `json:"datatype"`
 	IsCategory   bool   `json:"is_category"`
 	IsCustom     bool   `json:"is_custom"`
 	IsEditable   bool   `json:"is_editable"`
@@ -80,7 +81,10 @@ func (f *Fields) SetMeta(name string, meta Meta) *Fields {
 }
 
 func (f *Fields) GetMeta(name string) string {
-	return f.GetField(name).String()
+	if field := f.GetField(name); field != nil {
+		return field.String()
+	}
+	return ""
 }
 
 func (f *Fields) EachField() fields {
@@ -186,6 +190,46 @@ func (f *Field) String() string {
 	return f.Meta.String(f)
 }
 
+// CliString joins collections the way calibredb expects them.
+func (f *Field) CliString() string {
+	if f.IsCollection() {
+		sep := cliItemSep
+		if f.IsNames {
+			sep = cliNameSep
+		}
+		return strings.Join(f.Collection().StringSlice(), sep)
+	}
+	return f.String()
+}
+
+// SetString replaces the field's value, collections are split on their
+// separator with or without the surrounding spaces.
+func (f *Field) SetString(value string) *Field {
+	switch {
+	case f.IsCollection():
+		sep := strings.TrimSpace(itemSep)
+		if f.IsNames {
+			sep = strings.TrimSpace(nameSep)
+		}
+		var vals []string
+		for _, v := range strings.Split(value, sep) {
+			if v = strings.TrimSpace(v); v != "" {
+				vals = append(vals, v)
+			}
+		}
+		f.Meta = NewMetaCollection()
+		return f.SetMeta(vals)
+	case f.IsItem():
+		f.Item().Set("value", strings.TrimSpace(value))
+		return f
+	case f.IsColumn():
+		f.Meta = NewMetaColumn()
+		return f.SetMeta(strings.TrimSpace(value))
+	default:
+		return f.SetMeta(strings.TrimSpace(value))
+	}
+}
+
 func (f *Field) RawData() interface{} {
 	return f.Meta.RawData()
 }
@@ -324,5 +368,6 @@ func defaultCalibreFields() fields {
 		"uuid":           NewColumn("uuid"),
 		"customColumns": NewCollection("customColumns").
 			SetCalibreLabel("custom_columns"),
+		"chapters": NewCollection("chapters"),
 	}
 }
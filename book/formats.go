@@ -8,6 +8,7 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -95,55 +96,84 @@ type Fmt struct {
 	name   string
 	hash   bool
 	data   []byte
-	render func(b *Book, hash bool) *bytes.Buffer
+	render RenderFunc
+	parse  ParseFunc
 }
 
+// Render renders the book, the error is from a template that failed.
+func (f Fmt) Render() (*bytes.Buffer, error) {
+	if f.render == nil {
+		return nil, fmt.Errorf("not a format")
+	}
+	return f.render(f.book, f.hash)
+}
+
+// String is Render without the error, a failed render is empty.
 func (f Fmt) String() string {
-	return f.render(f.book, f.hash).String()
+	return string(f.Bytes())
 }
 
+// Bytes is Render without the error, a failed render is empty.
 func (f Fmt) Bytes() []byte {
-	return f.render(f.book, f.hash).Bytes()
+	buf, err := f.Render()
+	if err != nil {
+		return nil
+	}
+	return buf.Bytes()
 }
 
-func (f Fmt) Print() {
-	fmt.Println(f.String())
+func (f Fmt) Print() error {
+	buf, err := f.Render()
+	if err != nil {
+		return err
+	}
+	fmt.Println(buf.String())
+	return nil
 }
 
-func (f Fmt) Write() {
+func (f Fmt) Write() error {
+	buf, err := f.Render()
+	if err != nil {
+		return err
+	}
+
 	file, err := os.Create(slug.Make(f.book.GetMeta("title")) + f.ext)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer file.Close()
 
-	_, err = file.Write(f.Bytes())
+	_, err = file.Write(buf.Bytes())
+	return err
+}
+
+func (f Fmt) Tmp() (*os.File, error) {
+	buf, err := f.Render()
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-}
 
-func (f Fmt) Tmp() *os.File {
 	file, err := os.CreateTemp("", f.ext)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	_, err = file.Write(f.Bytes())
+	_, err = file.Write(buf.Bytes())
 	if err != nil {
-		log.Fatal(err)
+		file.Close()
+		return nil, err
 	}
 
-	return file
+	return file, nil
 }
 
-func renderTmpl(b *Book, hash bool) *bytes.Buffer {
+func renderTmpl(b *Book, hash bool) (*bytes.Buffer, error) {
 	var buf bytes.Buffer
 	err := b.tmpl.Execute(&buf, b)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	return &buf
+	return &buf, nil
 }
 
 func ToToml(b *Book, hash bool) *bytes.Buffer {
@@ -197,11 +227,74 @@ func toMarkdown(str string) string {
 	return markdown
 }
 
+// identifierURL links an identifier stored as scheme:value to its site, or
+// returns an empty string for schemes without one.
+func identifierURL(id string) string {
+	scheme, val, ok := strings.Cut(id, ":")
+	if !ok {
+		return ""
+	}
+	switch strings.ToLower(scheme) {
+	case "audible":
+		return "https://www.audible.com/pd/" + val
+	case "amazon", "asin", "mobi-asin":
+		return "https://www.amazon.com/dp/" + val
+	case "isbn":
+		return "https://www.worldcat.org/isbn/" + val
+	case "goodreads":
+		return "https://www.goodreads.com/book/show/" + val
+	case "google":
+		return "https://books.google.com/books?id=" + val
+	case "url", "uri":
+		return val
+	}
+	return ""
+}
+
+func humanSize(size string) string {
+	b, err := strconv.ParseFloat(size, 64)
+	if err != nil {
+		return size
+	}
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	i := 0
+	for b >= 1024 && i < len(units)-1 {
+		b = b / 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", b, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", b, units[i])
+}
+
+// ExecuteTemplate renders the book with a user template, like the templates
+// registered with RegisterTemplate.
+func (b *Book) ExecuteTemplate(text string) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	tmpl, err := textTemplate("user", text)
+	if err != nil {
+		return nil, err
+	}
+	err = tmpl.Execute(&buf, b)
+	if err != nil {
+		return nil, fmt.Errorf("template execute error: %v\n", err)
+	}
+	return &buf, nil
+}
+
 var (
 	funcMap = template.FuncMap{
-		"toMarkdown":   toMarkdown,
-		"stringToHTML": stringToHTML,
-		"ToIni":        ToIni,
+		"toMarkdown":    toMarkdown,
+		"stringToHTML":  stringToHTML,
+		"ToIni":         ToIni,
+		"identifierURL": identifierURL,
+		"humanSize":     humanSize,
+		"trimHash":      func(s string) string { return strings.TrimPrefix(s, "#") },
+		"join":          join,
+		"date":          formatDate,
+		"slug":          slugify,
+		"truncate":      truncate,
 	}
 
 	MetaFmt = []Fmt{
@@ -225,6 +318,13 @@ var (
 			tmpl:   template.Must(template.New("md").Funcs(funcMap).Parse(mdTmpl)),
 			render: renderTmpl,
 		},
+		Fmt{
+			name:   "card",
+			ext:    ".md",
+			hash:   true,
+			tmpl:   template.Must(template.New("card").Funcs(funcMap).Parse(cardTmpl)),
+			render: renderTmpl,
+		},
 		Fmt{
 			name:   "plain",
 			ext:    ".txt",
@@ -234,23 +334,50 @@ var (
 		Fmt{
 			name:   "opf",
 			ext:    ".opf",
-			render: func(b *Book, hash bool) *bytes.Buffer { return buildOPF(b).Marshal() },
+			render: func(b *Book, hash bool) (*bytes.Buffer, error) { return buildOPF(b).Marshal(), nil },
 		},
 		Fmt{
 			name:   "ini",
 			ext:    ".ini",
-			render: ToIni,
+			render: func(b *Book, hash bool) (*bytes.Buffer, error) { return ToIni(b, hash), nil },
+			parse:  ParseIni,
 		},
 		Fmt{
 			name:   "toml",
 			ext:    ".toml",
 			hash:   true,
-			render: ToToml,
+			render: func(b *Book, hash bool) (*bytes.Buffer, error) { return ToToml(b, hash), nil },
+			parse:  ParseToml,
+		},
+		Fmt{
+			name:   "cue",
+			ext:    ".cue",
+			render: func(b *Book, hash bool) (*bytes.Buffer, error) { return ToCue(b), nil },
+		},
+		Fmt{
+			name:   "bibtex",
+			ext:    ".bib",
+			render: func(b *Book, hash bool) (*bytes.Buffer, error) { return ToBibtex(b), nil },
+		},
+		Fmt{
+			name:   "ris",
+			ext:    ".ris",
+			render: func(b *Book, hash bool) (*bytes.Buffer, error) { return ToRIS(b), nil },
+		},
+		Fmt{
+			name:   "csl-json",
+			ext:    ".json",
+			render: func(b *Book, hash bool) (*bytes.Buffer, error) { return ToCslJSON(b), nil },
+		},
+		Fmt{
+			name:   "jsonld",
+			ext:    ".json",
+			render: func(b *Book, hash bool) (*bytes.Buffer, error) { return ToJsonLD(b, hash), nil },
 		},
 		Fmt{
 			name:   "rss",
 			ext:    ".xml",
-			render: func(b *Book, hash bool) *bytes.Buffer { return BookToRssChannel(b).Marshal() },
+			render: func(b *Book, hash bool) (*bytes.Buffer, error) { return BookToRssChannel(b).Marshal(), nil },
 		},
 	}
 )
@@ -261,7 +388,14 @@ album={{with .GetTitleAndSeries}}{{stringToHTML .}}{{end}}
 artist={{with .GetMeta "authors"}}{{stringToHTML .}}{{end}}
 composer={{with .GetMeta "#narrators"}}{{stringToHTML .}}{{end}}
 genre={{with .GetMeta "tags"}}{{stringToHTML .}}{{end}}
-comment={{with .GetMeta "description"}}{{stringToHTML .}}{{end}}`
+comment={{with .GetMeta "description"}}{{stringToHTML .}}{{end}}
+{{range .Chapters}}
+[CHAPTER]
+TIMEBASE=1/1000
+START={{.Get "start"}}
+END={{.Get "end"}}
+title={{with .Get "value"}}{{stringToHTML .}}{{end}}
+{{end}}`
 
 const mdTmpl = `
 {{- with .GetMeta "title"}}# {{stringToHTML .}}{{end}}
@@ -272,6 +406,53 @@ const mdTmpl = `
 **Rating:** {{with .GetMeta "rating"}}{{stringToHTML .}}{{end}}
 **Description:** {{with .GetMeta "description"}}{{toMarkdown .}}{{end}}`
 
+const cardTmpl = `
+{{- with .GetMeta "title"}}# {{stringToHTML .}}{{end}}
+{{with .GetSeriesString}}
+*{{stringToHTML .}}*
+{{end}}
+{{- with .GetMeta "authors"}}
+**Authors:** {{stringToHTML .}}
+{{end}}
+{{- with .GetMeta "publisher"}}
+**Publisher:** {{stringToHTML .}}
+{{end}}
+{{- with .GetMeta "published"}}
+**Published:** {{stringToHTML .}}
+{{end}}
+{{- with .GetMeta "tags"}}
+**Tags:** {{stringToHTML .}}
+{{end}}
+{{- with .GetMeta "rating"}}
+**Rating:** {{stringToHTML .}}
+{{end}}
+{{- with .GetFile "cover"}}{{with .Get "path"}}
+**Cover:** {{stringToHTML .}}
+{{end}}{{end}}
+{{- with .GetField "formats"}}{{if not .IsNull}}
+## Formats
+{{range .Collection.EachItem}}
+- **{{.Get "extension"}}** ({{humanSize (.Get "size")}}) {{stringToHTML (.Get "path")}}
+{{- end}}
+{{end}}{{end}}
+{{- with .GetField "identifiers"}}{{if not .IsNull}}
+## Identifiers
+{{range .Collection.EachItem}}{{$id := .Get "value"}}
+- {{with identifierURL $id}}[{{stringToHTML $id}}]({{stringToHTML .}}){{else}}{{stringToHTML $id}}{{end}}
+{{- end}}
+{{end}}{{end}}
+{{- with .CustomColumns}}
+## Custom Columns
+{{range .}}
+- **{{trimHash .Label}}:** {{stringToHTML .String}}
+{{- end}}
+{{end}}
+{{- with .GetMeta "description"}}
+## Description
+
+{{toMarkdown .}}
+{{end}}`
+
 const plainTmpl = `
 {{- with .GetMeta "title"}}{{stringToHTML .}}{{end}}
 Series: {{with .GetSeriesString}}{{stringToHTML .}}{{end}}
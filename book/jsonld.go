@@ -0,0 +1,201 @@
+package book
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+type jsonLD struct {
+	Context         string           `json:"@context"`
+	Type            string           `json:"@type"`
+	Name            string           `json:"name"`
+	URL             string           `json:"url,omitempty"`
+	Image           string           `json:"image,omitempty"`
+	Description     string           `json:"description,omitempty"`
+	Author          []ldPerson       `json:"author,omitempty"`
+	ReadBy          []ldPerson       `json:"readBy,omitempty"`
+	IsPartOf        *ldSeries        `json:"isPartOf,omitempty"`
+	Duration        string           `json:"duration,omitempty"`
+	ISBN            string           `json:"isbn,omitempty"`
+	InLanguage      any              `json:"inLanguage,omitempty"`
+	Publisher       *ldOrganization  `json:"publisher,omitempty"`
+	DatePublished   string           `json:"datePublished,omitempty"`
+	Genre           []string         `json:"genre,omitempty"`
+	AggregateRating *ldAggregateRate `json:"aggregateRating,omitempty"`
+}
+
+type ldPerson struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+type ldSeries struct {
+	Type     string `json:"@type"`
+	Name     string `json:"name"`
+	Position string `json:"position,omitempty"`
+}
+
+type ldOrganization struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+type ldAggregateRate struct {
+	Type        string `json:"@type"`
+	RatingValue string `json:"ratingValue"`
+	BestRating  string `json:"bestRating"`
+	WorstRating string `json:"worstRating"`
+	RatingCount int    `json:"ratingCount"`
+}
+
+// ToJsonLD renders the book as schema.org structured data, an Audiobook if
+// it has an audio format, otherwise a Book.
+func ToJsonLD(b *Book, hash bool) *bytes.Buffer {
+	ld := jsonLD{
+		Context:     "https://schema.org",
+		Type:        "Book",
+		Name:        b.GetMeta("title"),
+		Image:       b.GetFile("cover").Get("url"),
+		Description: b.citationAbstract(),
+		Author:      ldPeople(b.GetField("authors")),
+		ISBN:        b.GetIdentifier("isbn"),
+		Genre:       b.citationKeywords(),
+	}
+
+	if uri := b.GetField("uri"); !uri.IsNull() {
+		ld.URL = uri.String()
+	}
+
+	if b.IsAudiobook() {
+		ld.Type = "Audiobook"
+		ld.ReadBy = ldPeople(b.GetField("#narrators"))
+		if f := b.GetField("#duration"); f != nil && !f.IsNull() {
+			if d := ldDuration(f.String()); d > 0 {
+				ld.Duration = isoDuration(d)
+			}
+		}
+	}
+
+	if s := b.GetMeta("series"); s != "" {
+		ld.IsPartOf = &ldSeries{
+			Type:     "BookSeries",
+			Name:     s,
+			Position: b.citationPosition(),
+		}
+	}
+
+	if f := b.GetField("languages"); f != nil && !f.IsNull() {
+		langs := f.Collection().StringSlice()
+		ld.InLanguage = langs
+		if len(langs) == 1 {
+			ld.InLanguage = langs[0]
+		}
+	}
+
+	if p := b.GetMeta("publisher"); p != "" {
+		ld.Publisher = &ldOrganization{Type: "Organization", Name: p}
+	}
+
+	if t, ok := b.publishedDate(); ok {
+		ld.DatePublished = t.Format("2006-01-02")
+	}
+
+	// calibre ratings are out of 10, shown as 5 stars
+	if r, err := strconv.ParseFloat(b.GetMeta("rating"), 64); err == nil && r > 0 {
+		ld.AggregateRating = &ldAggregateRate{
+			Type:        "AggregateRating",
+			RatingValue: strconv.FormatFloat(r/2, 'f', -1, 64),
+			BestRating:  "5",
+			WorstRating: "1",
+			RatingCount: 1,
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(ld); err != nil {
+		log.Fatal(err)
+	}
+	return &buf
+}
+
+// IsAudiobook is true if one of the book's formats is an audio format.
+func (b *Book) IsAudiobook() bool {
+	f := b.GetField("formats")
+	if f == nil || f.IsNull() {
+		return false
+	}
+	for _, i := range f.Collection().EachItem() {
+		if slices.Contains(AudioFormats(), i.Get("extension")) {
+			return true
+		}
+	}
+	return false
+}
+
+func ldPeople(f *Field) []ldPerson {
+	var people []ldPerson
+	if f == nil || f.IsNull() {
+		return people
+	}
+	if !f.IsCollection() {
+		return append(people, ldPerson{Type: "Person", Name: f.String()})
+	}
+	for _, name := range f.Collection().StringSlice() {
+		people = append(people, ldPerson{Type: "Person", Name: name})
+	}
+	return people
+}
+
+// ldDuration reads a duration written as hh:mm:ss, hh:mm or a go duration
+// like 10h5m.
+func ldDuration(s string) time.Duration {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, ":") {
+		d, _ := time.ParseDuration(strings.ReplaceAll(s, " ", ""))
+		return d
+	}
+
+	var d time.Duration
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	for i, p := range strings.Split(s, ":") {
+		if i >= len(units) {
+			break
+		}
+		n, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0
+		}
+		d += time.Duration(n * float64(units[i]))
+	}
+	return d
+}
+
+// isoDuration formats a duration as ISO 8601, eg PT10H5M.
+func isoDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+
+	iso := "PT"
+	if h > 0 {
+		iso += fmt.Sprintf("%dH", h)
+	}
+	if m > 0 {
+		iso += fmt.Sprintf("%dM", m)
+	}
+	if s > 0 || iso == "PT" {
+		iso += fmt.Sprintf("%dS", s)
+	}
+	return iso
+}
@@ -0,0 +1,108 @@
+package book
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func jsonLDBook(formats ...string) *Book {
+	b := NewBook()
+	b.GetField("title").SetMeta("The Hobbit")
+	b.GetField("authors").SetMeta([]string{"J. R. R. Tolkien"})
+	b.GetField("series").Item().Set("value", "Middle-earth").Set("position", "1")
+	b.GetField("rating").SetMeta("8")
+	b.AddField(NewCollection("#narrators")).SetIsNames().SetMeta([]string{"Andy Serkis", "Rob Inglis"})
+	b.AddField(NewColumn("#duration")).SetMeta("10:25:30")
+	for _, ext := range formats {
+		b.GetField("formats").Collection().AddItem().Set("extension", ext)
+	}
+	return b
+}
+
+func decodeJsonLD(t *testing.T, b *Book) jsonLD {
+	t.Helper()
+	var ld jsonLD
+	if err := json.Unmarshal(ToJsonLD(b, false).Bytes(), &ld); err != nil {
+		t.Fatal(err)
+	}
+	return ld
+}
+
+func TestJsonLDType(t *testing.T) {
+	for _, test := range []struct {
+		formats []string
+		want    string
+	}{
+		{nil, "Book"},
+		{[]string{"epub"}, "Book"},
+		{[]string{"epub", "m4b"}, "Audiobook"},
+		{[]string{"mp3"}, "Audiobook"},
+	} {
+		ld := decodeJsonLD(t, jsonLDBook(test.formats...))
+		if ld.Type != test.want {
+			t.Errorf("%v: type = %v, want %v", test.formats, ld.Type, test.want)
+		}
+		if test.want == "Book" && (len(ld.ReadBy) > 0 || ld.Duration != "") {
+			t.Errorf("%v: a book has readBy %v and duration %v", test.formats, ld.ReadBy, ld.Duration)
+		}
+	}
+}
+
+func TestJsonLDAudiobook(t *testing.T) {
+	ld := decodeJsonLD(t, jsonLDBook("m4b"))
+
+	if len(ld.ReadBy) != 2 || ld.ReadBy[0].Name != "Andy Serkis" || ld.ReadBy[1].Name != "Rob Inglis" {
+		t.Errorf("readBy = %+v", ld.ReadBy)
+	}
+	for _, p := range ld.ReadBy {
+		if p.Type != "Person" {
+			t.Errorf("narrator %v is a %v", p.Name, p.Type)
+		}
+	}
+	if ld.Duration != "PT10H25M30S" {
+		t.Errorf("duration = %v, want PT10H25M30S", ld.Duration)
+	}
+}
+
+func TestJsonLDSeries(t *testing.T) {
+	ld := decodeJsonLD(t, jsonLDBook())
+	want := ldSeries{Type: "BookSeries", Name: "Middle-earth", Position: "1"}
+	if ld.IsPartOf == nil || *ld.IsPartOf != want {
+		t.Errorf("isPartOf = %+v, want %+v", ld.IsPartOf, want)
+	}
+}
+
+func TestJsonLDRating(t *testing.T) {
+	ld := decodeJsonLD(t, jsonLDBook())
+	want := ldAggregateRate{
+		Type:        "AggregateRating",
+		RatingValue: "4",
+		BestRating:  "5",
+		WorstRating: "1",
+		RatingCount: 1,
+	}
+	if ld.AggregateRating == nil || *ld.AggregateRating != want {
+		t.Errorf("aggregateRating = %+v, want %+v", ld.AggregateRating, want)
+	}
+
+	b := jsonLDBook()
+	b.GetField("rating").SetMeta("")
+	if ld := decodeJsonLD(t, b); ld.AggregateRating != nil {
+		t.Errorf("unrated book has aggregateRating %+v", ld.AggregateRating)
+	}
+}
+
+func TestIsoDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                                    "PT0S",
+		45 * time.Second:                     "PT45S",
+		90 * time.Minute:                     "PT1H30M",
+		10*time.Hour + 5*time.Second:         "PT10H5S",
+		26*time.Hour + 1500*time.Millisecond: "PT26H2S",
+	} {
+		if got := isoDuration(d); got != want {
+			t.Errorf("isoDuration(%v) = %v, want %v", d, got, want)
+		}
+	}
+}
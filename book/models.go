@@ -2,14 +2,14 @@ package book
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
 )
 
-func UnmarshalAudibleApiProduct(d []byte) *Book {
+func UnmarshalAudibleApiProduct(d []byte) (*Book, error) {
 	var data map[string]json.RawMessage
 	err := json.Unmarshal(d, &data)
 	if err != nil {
-		log.Fatalf("issue unmarshalling audible api book %v\n", err)
+		return nil, fmt.Errorf("issue unmarshalling audible api book %v\n", err)
 	}
 
 	book := NewBook()
@@ -19,7 +19,7 @@ func UnmarshalAudibleApiProduct(d []byte) *Book {
 			var c []map[string]string
 			err := json.Unmarshal(dd, &c)
 			if err != nil {
-				log.Fatal(err)
+				return nil, fmt.Errorf("issue unmarshalling audible api %v %v\n", f, err)
 			}
 
 			if f == "series" {
@@ -45,11 +45,11 @@ func UnmarshalAudibleApiProduct(d []byte) *Book {
 				cc = append(cc, contributor["name"])
 			}
 			contributors.SetMeta(cc)
-		case "title", "release_date", "publisher_summary", "language", "publisher_name":
+		case "title", "release_date", "publisher_summary", "language", "publisher_name", "asin":
 			var val string
 			err := json.Unmarshal(dd, &val)
 			if err != nil {
-				log.Fatal(err)
+				return nil, fmt.Errorf("issue unmarshalling audible api %v %v\n", f, err)
 			}
 			switch f {
 			case "title":
@@ -62,16 +62,39 @@ func UnmarshalAudibleApiProduct(d []byte) *Book {
 				book.GetField("languages").SetMeta(val)
 			case "publisher_name":
 				book.GetField("publisher").SetMeta(val)
+			case "asin":
+				book.GetField("identifiers").Collection().AddItem().
+					Set("value", "audible:"+val).
+					Set("type", "audible")
 			}
 		case "product_images":
 			var val = make(map[string]string)
 			err := json.Unmarshal(dd, &val)
 			if err != nil {
-				log.Fatal(err)
+				return nil, fmt.Errorf("issue unmarshalling audible api %v %v\n", f, err)
 			}
 			book.GetField("cover").Item().Set("url", val["500"])
+		case "content_metadata":
+			var meta map[string]json.RawMessage
+			err := json.Unmarshal(dd, &meta)
+			if err != nil {
+				return nil, fmt.Errorf("issue unmarshalling audible api %v %v\n", f, err)
+			}
+			if chaps, ok := meta["chapter_info"]; ok {
+				err := book.setAudibleChapters(chaps)
+				if err != nil {
+					return nil, err
+				}
+			}
 		case "runtime_length_min":
+			var min int
+			err := json.Unmarshal(dd, &min)
+			if err != nil {
+				return nil, fmt.Errorf("issue unmarshalling audible api %v %v\n", f, err)
+			}
+			book.AddField(NewColumn("#duration")).SetIsEditable().SetIsCustom().
+				SetMeta(fmt.Sprintf("%02d:%02d:00", min/60, min%60))
 		}
 	}
-	return book
+	return book, nil
 }
@@ -0,0 +1,167 @@
+package book
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"github.com/gosimple/slug"
+	"gopkg.in/ini.v1"
+)
+
+// RenderFunc renders a book, with hash the custom column labels keep their
+// leading #.
+type RenderFunc func(b *Book, hash bool) (*bytes.Buffer, error)
+
+// ParseFunc reads a book back from a format.
+type ParseFunc func(d []byte) (*Book, error)
+
+// RegisterFormat adds a format to MetaFmt, replacing a format with the same
+// name. parse may be nil for formats that can't be read back.
+func RegisterFormat(name, ext string, render RenderFunc, parse ParseFunc) {
+	f := Fmt{
+		name:   name,
+		ext:    ext,
+		render: render,
+		parse:  parse,
+	}
+	for i, m := range MetaFmt {
+		if m.name == name {
+			MetaFmt[i] = f
+			return
+		}
+	}
+	MetaFmt = append(MetaFmt, f)
+}
+
+// RegisterTemplate registers a go template as a format. Templates have the
+// same functions as the builtin formats, and their output isn't html escaped.
+func RegisterTemplate(name, ext, text string) error {
+	tmpl, err := textTemplate(name, text)
+	if err != nil {
+		return err
+	}
+	render := func(b *Book, hash bool) (*bytes.Buffer, error) {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, b); err != nil {
+			return nil, fmt.Errorf("%v format error: %v\n", name, err)
+		}
+		return &buf, nil
+	}
+	RegisterFormat(name, ext, render, nil)
+	return nil
+}
+
+// ParseFormat reads a book from data in the named format.
+func ParseFormat(name string, d []byte) (*Book, error) {
+	for _, f := range MetaFmt {
+		if f.name == name {
+			if f.parse == nil {
+				return nil, fmt.Errorf("%v format can't be parsed\n", name)
+			}
+			return f.parse(d)
+		}
+	}
+	return nil, fmt.Errorf("%v is not a format\n", name)
+}
+
+func textTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(template.FuncMap(funcMap)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template %v parse error: %v\n", name, err)
+	}
+	return tmpl, nil
+}
+
+// ParseToml reads a book from toml as written by the toml format.
+func ParseToml(d []byte) (*Book, error) {
+	meta := make(map[string]string)
+	_, err := toml.Decode(string(d), &meta)
+	if err != nil {
+		return nil, fmt.Errorf("toml parse error: %v\n", err)
+	}
+	return stringMapToBook(meta), nil
+}
+
+// ParseIni reads a book from ini as written by the ini format.
+func ParseIni(d []byte) (*Book, error) {
+	file, err := ini.LoadSources(iniOpts, d)
+	if err != nil {
+		return nil, fmt.Errorf("ini parse error: %v\n", err)
+	}
+	return stringMapToBook(file.Section("").KeysHash()), nil
+}
+
+func stringMapToBook(meta map[string]string) *Book {
+	b := NewBook()
+	for key, val := range meta {
+		field := b.GetField(key)
+		if field == nil {
+			if !strings.HasPrefix(key, "#") {
+				key = "#" + key
+			}
+			field = b.AddField(NewColumn(key)).SetIsCustom().SetIsEditable()
+			b.customColumns = append(b.customColumns, key)
+		}
+		field.SetMeta(val)
+	}
+	return b
+}
+
+var templateDateFormats = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// formatDate formats a date with a go time layout, the value is returned as
+// is if it isn't a date.
+func formatDate(layout, value string) string {
+	if t, ok := parseDate(value); ok {
+		return t.Format(layout)
+	}
+	return value
+}
+
+func parseDate(value string) (time.Time, bool) {
+	for _, f := range templateDateFormats {
+		if t, err := time.Parse(f, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// join joins the values of a collection with sep, other fields are returned
+// as their string.
+func join(sep string, f *Field) string {
+	if f == nil {
+		return ""
+	}
+	if f.IsCollection() {
+		return strings.Join(f.Collection().StringSlice(), sep)
+	}
+	return f.String()
+}
+
+// truncate shortens s to n characters, ending with an ellipsis.
+func truncate(n int, s string) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n < 1 {
+		return ""
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+func slugify(s string) string {
+	return slug.Make(s)
+}
@@ -0,0 +1,397 @@
+package bubbles
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"runtime"
+	"strconv"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ohzqq/urbooks-core/book"
+	"golang.org/x/exp/slices"
+)
+
+// BrowserSource is where the browser gets its books, pages start at 1 and
+// the last page is returned with each page of books.
+type BrowserSource interface {
+	Name() string
+	Books(page int) (book.Books, int)
+	Category(label string) []*book.Item
+	HiddenCategories() []string
+	CategoryBooks(label, id string, page int) (book.Books, int)
+	Export(b *book.Book) error
+	FilePath(b *book.Book) string
+}
+
+const (
+	booksView = iota
+	catMenuView
+	catView
+	detailView
+)
+
+// Browser is a full screen library browser. Editing a book needs the
+// terminal, so Browse returns with the edit action and can be called again
+// to pick up where it left off.
+type Browser struct {
+	source   BrowserSource
+	header   *headerBar
+	keyMap   KeyMap
+	view     int
+	books    list.Model
+	menu     list.Model
+	cat      list.Model
+	detail   viewport.Model
+	current  book.Books
+	selected *book.Book
+	catLabel string
+	catID    string
+	catTitle string
+	page     int
+	lastPage int
+	status   string
+	action   string
+	width    int
+	height   int
+}
+
+func NewBrowser(src BrowserSource) *Browser {
+	w, h := TermSize()
+
+	menu := NewList().SetTitle("Categories").SetWidth(w).SetHeight(h - 2)
+	hidden := src.HiddenCategories()
+	for i, label := range book.BookCats() {
+		if slices.Contains(hidden, label) {
+			continue
+		}
+		menu.AppendItem(item{title: book.BookCatsTitle(i), id: label})
+	}
+
+	b := &Browser{
+		source: src,
+		header: newHeader(),
+		keyMap: browserKeyMap(),
+		books:  NewList().SetWidth(w).SetHeight(h - 2).Model(),
+		menu:   menu.Model(),
+		cat:    NewList().SetWidth(w).SetHeight(h - 2).Model(),
+		detail: viewport.New(w, h-2),
+		page:   1,
+		width:  w,
+		height: h,
+	}
+	// q goes back a screen instead of quitting
+	for _, l := range []*list.Model{&b.books, &b.menu, &b.cat} {
+		l.KeyMap.Quit.SetEnabled(false)
+	}
+	b.header.setCol2(src.Name())
+	b.loadBooks()
+
+	return b
+}
+
+// Browse runs the browser until it's quit or an action that needs the
+// terminal is chosen, the action and its book are returned.
+func (b *Browser) Browse() (string, *book.Book) {
+	b.action = ""
+	p := tea.NewProgram(b, tea.WithAltScreen())
+	if err := p.Start(); err != nil {
+		log.Fatal(err)
+	}
+	return b.action, b.selected
+}
+
+// Reload fetches the current page again, eg after a book was edited.
+func (b *Browser) Reload() {
+	b.loadBooks()
+	if b.selected != nil {
+		for _, bk := range b.current {
+			if bk.GetMeta("id") == b.selected.GetMeta("id") {
+				b.showDetail(bk)
+			}
+		}
+	}
+}
+
+func (b *Browser) loadBooks() {
+	var books book.Books
+	if b.catLabel != "" {
+		books, b.lastPage = b.source.CategoryBooks(b.catLabel, b.catID, b.page)
+	} else {
+		books, b.lastPage = b.source.Books(b.page)
+	}
+	b.current = books
+
+	var items []list.Item
+	for i, bk := range books {
+		title := bk.GetTitleAndSeries()
+		if a := bk.GetMeta("authors"); a != "" {
+			title = title + " by " + a
+		}
+		items = append(items, item{
+			title:  title,
+			id:     strconv.Itoa(i),
+			filter: bk.FilterValue(),
+		})
+	}
+	b.books.SetItems(items)
+	b.books.Title = "Books"
+	if b.catTitle != "" {
+		b.books.Title = b.catTitle
+	}
+	b.books.ResetSelected()
+
+	b.header.setCol3(b.books.Title)
+	b.header.setCol4(fmt.Sprintf("page %d/%d", b.page, b.lastPage))
+}
+
+func (b *Browser) loadCategory(label, title string) {
+	var items []list.Item
+	for _, i := range b.source.Category(label) {
+		items = append(items, item{
+			title:  fmt.Sprintf("%s (%d)", i.Get("value"), i.TotalBooks()),
+			id:     i.Get("id"),
+			filter: i.Get("value"),
+		})
+	}
+	b.cat.SetItems(items)
+	b.cat.Title = title
+	b.cat.ResetSelected()
+	b.catLabel = label
+	b.header.setCol3(title)
+}
+
+func (b *Browser) showDetail(bk *book.Book) {
+	b.selected = bk
+	buf, err := bk.ConvertTo("markdown").Render()
+	if err != nil {
+		b.detail.SetContent(err.Error())
+	} else {
+		b.detail.SetContent(RenderMarkdown(buf.String()))
+	}
+	b.detail.GotoTop()
+	b.header.setCol3(bk.GetMeta("title"))
+}
+
+func (b *Browser) Init() tea.Cmd {
+	return nil
+}
+
+func (b *Browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.width, b.height = msg.Width, msg.Height
+		b.header.width = msg.Width
+		b.header.model.SetSize(msg.Width)
+		for _, l := range []*list.Model{&b.books, &b.menu, &b.cat} {
+			l.SetSize(msg.Width, msg.Height-2)
+		}
+		b.detail.Width = msg.Width
+		b.detail.Height = msg.Height - 2
+		return b, nil
+	case tea.KeyMsg:
+		if b.filtering() {
+			break
+		}
+		b.status = ""
+		if key.Matches(msg, b.keyMap["Quit"]) {
+			return b, tea.Quit
+		}
+		if cmd, ok := b.handleKey(msg); ok {
+			return b, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	switch b.view {
+	case booksView:
+		b.books, cmd = b.books.Update(msg)
+	case catMenuView:
+		b.menu, cmd = b.menu.Update(msg)
+	case catView:
+		b.cat, cmd = b.cat.Update(msg)
+	case detailView:
+		b.detail, cmd = b.detail.Update(msg)
+	}
+	return b, cmd
+}
+
+func (b *Browser) filtering() bool {
+	switch b.view {
+	case booksView:
+		return b.books.FilterState() == list.Filtering
+	case catMenuView:
+		return b.menu.FilterState() == list.Filtering
+	case catView:
+		return b.cat.FilterState() == list.Filtering
+	}
+	return false
+}
+
+// handleKey returns false when the key should be passed on to the current
+// view.
+func (b *Browser) handleKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch b.view {
+	case booksView:
+		switch {
+		case key.Matches(msg, b.keyMap["Enter"]):
+			if i, ok := b.books.SelectedItem().(item); ok {
+				n, _ := strconv.Atoi(i.ID())
+				b.showDetail(b.current[n])
+				b.view = detailView
+			}
+		case key.Matches(msg, b.keyMap["CategoryList"]):
+			b.view = catMenuView
+			b.header.setCol3("Categories")
+		case key.Matches(msg, b.keyMap["NextPage"]):
+			if b.page < b.lastPage {
+				b.page++
+				b.loadBooks()
+			}
+		case key.Matches(msg, b.keyMap["PrevPage"]):
+			if b.page > 1 {
+				b.page--
+				b.loadBooks()
+			}
+		case key.Matches(msg, b.keyMap["ExitScreen"]):
+			if b.catLabel == "" {
+				return tea.Quit, true
+			}
+			b.view = catView
+			b.header.setCol3(b.cat.Title)
+		default:
+			return nil, false
+		}
+	case catMenuView:
+		switch {
+		case key.Matches(msg, b.keyMap["Enter"]):
+			if i, ok := b.menu.SelectedItem().(item); ok {
+				b.loadCategory(i.ID(), i.Title())
+				b.view = catView
+			}
+		case key.Matches(msg, b.keyMap["ExitScreen"]):
+			b.view = booksView
+			b.header.setCol3(b.books.Title)
+		default:
+			return nil, false
+		}
+	case catView:
+		switch {
+		case key.Matches(msg, b.keyMap["Enter"]):
+			if i, ok := b.cat.SelectedItem().(item); ok {
+				b.catID = i.ID()
+				b.catTitle = i.FilterValue()
+				b.page = 1
+				b.loadBooks()
+				b.view = booksView
+			}
+		case key.Matches(msg, b.keyMap["ExitScreen"]):
+			b.catLabel, b.catID, b.catTitle = "", "", ""
+			b.page = 1
+			b.loadBooks()
+			b.view = catMenuView
+			b.header.setCol3("Categories")
+		default:
+			return nil, false
+		}
+	case detailView:
+		switch {
+		case key.Matches(msg, b.keyMap["EditField"]):
+			b.action = "edit"
+			return tea.Quit, true
+		case key.Matches(msg, b.keyMap["Export"]):
+			b.status = "exported"
+			if err := b.source.Export(b.selected); err != nil {
+				b.status = err.Error()
+			}
+		case key.Matches(msg, b.keyMap["OpenFile"]):
+			b.status = "opening " + b.source.FilePath(b.selected)
+			if err := openFile(b.source.FilePath(b.selected)); err != nil {
+				b.status = err.Error()
+			}
+		case key.Matches(msg, b.keyMap["CopyPath"]):
+			b.status = "copied " + b.source.FilePath(b.selected)
+			if err := clipboard.WriteAll(b.source.FilePath(b.selected)); err != nil {
+				b.status = err.Error()
+			}
+		case key.Matches(msg, b.keyMap["ExitScreen"]):
+			b.view = booksView
+			b.header.setCol3(b.books.Title)
+		default:
+			return nil, false
+		}
+	}
+	return nil, true
+}
+
+func openFile(path string) error {
+	if path == "" {
+		return fmt.Errorf("book has no files")
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	return cmd.Start()
+}
+
+func (b *Browser) View() string {
+	var view string
+	switch b.view {
+	case booksView:
+		view = b.books.View()
+	case catMenuView:
+		view = b.menu.View()
+	case catView:
+		view = b.cat.View()
+	case detailView:
+		view = b.detail.View()
+	}
+
+	footer := lipgloss.NewStyle().Foreground(lipgloss.Color(Theme.Grey)).PaddingLeft(2)
+	status := b.status
+	if status == "" && b.view == detailView {
+		status = "e edit • x export • o open • y copy path • q back"
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, b.header.model.View(), view, footer.Render(status))
+}
+
+func browserKeyMap() KeyMap {
+	keys := DefaultKeyMap()
+	keys["Quit"] = key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	)
+	keys["NextPage"] = key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next page"),
+	)
+	keys["PrevPage"] = key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "prev page"),
+	)
+	keys["Export"] = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "export"),
+	)
+	keys["OpenFile"] = key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open file"),
+	)
+	keys["CopyPath"] = key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy path"),
+	)
+	return keys
+}
@@ -0,0 +1,437 @@
+package bubbles
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ohzqq/urbooks-core/book"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	textInput = iota
+	areaInput
+	numberInput
+	dateInput
+	chipsInput
+)
+
+var dateFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// Editor is a form for editing the metadata of a book.
+type Editor struct {
+	book   *book.Book
+	fields []*editorField
+	focus  int
+	keyMap KeyMap
+	saved  bool
+	width  int
+	height int
+}
+
+type editorField struct {
+	label   string
+	kind    int
+	isNames bool
+	input   textinput.Model
+	area    textarea.Model
+	chips   []string
+	initial string
+	check   func(string) error
+	err     error
+}
+
+func NewEditor(b *book.Book) *Editor {
+	width := TermWidth()
+	if width <= 0 {
+		width = 80
+	}
+
+	e := &Editor{
+		book:   b.Copy(),
+		keyMap: editorKeyMap(),
+		width:  width,
+		height: TermHeight(),
+	}
+
+	for _, label := range editorFields(b) {
+		e.fields = append(e.fields, newEditorField(b.GetField(label), width))
+	}
+
+	if len(e.fields) > 0 {
+		e.fields[0].focus()
+	}
+
+	return e
+}
+
+// editorFields lists the editable fields followed by the custom columns.
+func editorFields(b *book.Book) []string {
+	var labels []string
+	for _, label := range book.EditableFields {
+		if label == "id" {
+			continue
+		}
+		if f := b.GetField(label); f != nil {
+			labels = append(labels, label)
+		}
+	}
+
+	var custom []string
+	for label, f := range b.EachField() {
+		if f.IsCustom && f.IsEditable && !slices.Contains(labels, label) {
+			custom = append(custom, label)
+		}
+	}
+	slices.Sort(custom)
+
+	return append(labels, custom...)
+}
+
+func newEditorField(f *book.Field, width int) *editorField {
+	field := &editorField{
+		label:   f.Label(),
+		isNames: f.IsNames,
+		kind:    textInput,
+		check:   f.Validate,
+	}
+
+	switch {
+	case f.IsCollection():
+		field.kind = chipsInput
+		field.chips = f.Collection().StringSlice()
+	case field.label == "description", f.Datatype == "comments":
+		field.kind = areaInput
+	case field.label == "rating", field.label == "position":
+		field.kind = numberInput
+	case field.label == "published", field.label == "added", f.Datatype == "datetime":
+		field.kind = dateInput
+	}
+
+	input := textinput.New()
+	input.Prompt = ""
+	input.Width = width - 20
+	switch field.kind {
+	case chipsInput:
+		input.Placeholder = "enter to add"
+	case dateInput:
+		input.Placeholder = "yyyy-mm-dd"
+	}
+
+	if field.kind == areaInput {
+		area := textarea.New()
+		area.CharLimit = 0
+		area.ShowLineNumbers = false
+		area.SetWidth(width - 20)
+		area.SetHeight(6)
+		area.SetValue(f.String())
+		field.area = area
+	} else if field.kind != chipsInput {
+		input.SetValue(f.String())
+	}
+	field.input = input
+
+	field.initial = field.value()
+
+	return field
+}
+
+// Edit runs the editor and returns the edited book, ok is false if the edit
+// was cancelled.
+func (e *Editor) Edit() (*book.Book, bool) {
+	p := tea.NewProgram(e)
+	if err := p.Start(); err != nil {
+		log.Fatal(err)
+	}
+	if !e.saved {
+		return nil, false
+	}
+	return e.book, true
+}
+
+// Changed lists the fields that were modified.
+func (e *Editor) Changed() []string {
+	var changed []string
+	for _, f := range e.fields {
+		if f.value() != f.initial {
+			changed = append(changed, f.label)
+		}
+	}
+	return changed
+}
+
+func (e *Editor) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (e *Editor) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		e.width = msg.Width
+		e.height = msg.Height
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, e.keyMap["Quit"]):
+			return e, tea.Quit
+		case key.Matches(msg, e.keyMap["Save"]):
+			if e.validate() {
+				e.save()
+				e.saved = true
+				return e, tea.Quit
+			}
+			return e, nil
+		case key.Matches(msg, e.keyMap["NextField"]):
+			return e, e.moveFocus(1)
+		case key.Matches(msg, e.keyMap["PrevField"]):
+			return e, e.moveFocus(-1)
+		}
+	}
+
+	if len(e.fields) == 0 {
+		return e, nil
+	}
+	return e, e.fields[e.focus].update(msg)
+}
+
+func (e *Editor) moveFocus(n int) tea.Cmd {
+	if len(e.fields) == 0 {
+		return nil
+	}
+	cur := e.fields[e.focus]
+	cur.validate()
+	cur.blur()
+	e.focus = (e.focus + n + len(e.fields)) % len(e.fields)
+	return e.fields[e.focus].focus()
+}
+
+func (e *Editor) validate() bool {
+	ok := true
+	for i, f := range e.fields {
+		if f.validate() != nil && ok {
+			ok = false
+			e.fields[e.focus].blur()
+			e.focus = i
+			f.focus()
+		}
+	}
+	return ok
+}
+
+func (e *Editor) save() {
+	for _, f := range e.fields {
+		if f.value() == f.initial {
+			continue
+		}
+		field := e.book.GetField(f.label)
+		switch {
+		case field.IsCollection():
+			field.Meta = book.NewMetaCollection()
+			field.SetMeta(f.chips)
+		case field.IsItem():
+			field.Item().Set("value", f.value())
+		default:
+			field.SetString(f.value())
+		}
+	}
+}
+
+func (e *Editor) View() string {
+	var (
+		labelStyle = lipgloss.NewStyle().Width(16).Foreground(lipgloss.Color(Theme.Purple))
+		focusStyle = labelStyle.Copy().Foreground(lipgloss.Color(Theme.Pink)).Bold(true)
+		errStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color(Theme.Red)).PaddingLeft(16)
+		helpStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color(Theme.Grey)).Padding(1, 0, 0, 2)
+	)
+
+	var (
+		blocks []string
+		offset int
+	)
+	for i, f := range e.fields {
+		label := labelStyle.Render(strings.TrimPrefix(f.label, "#"))
+		if i == e.focus {
+			label = focusStyle.Render(strings.TrimPrefix(f.label, "#"))
+		}
+
+		block := lipgloss.JoinHorizontal(lipgloss.Top, label, f.view())
+		if f.err != nil {
+			block = block + "\n" + errStyle.Render(f.err.Error())
+		}
+
+		if i == e.focus {
+			offset = len(blocks)
+		}
+		blocks = append(blocks, block)
+	}
+
+	help := helpStyle.Render("tab next • shift+tab prev • enter add item • ctrl+s save • esc cancel")
+
+	// scroll the focused field into view
+	height := e.height - lipgloss.Height(help)
+	start := 0
+	for height > 0 && start < offset && lipgloss.Height(strings.Join(blocks[start:offset+1], "\n")) > height {
+		start++
+	}
+
+	view := strings.Join(blocks[start:], "\n")
+	if height > 0 {
+		view = lipgloss.NewStyle().MaxHeight(height).Render(view)
+	}
+	return view + "\n" + help
+}
+
+func (f *editorField) value() string {
+	switch f.kind {
+	case areaInput:
+		return f.area.Value()
+	case chipsInput:
+		return strings.Join(f.chips, "\x00")
+	default:
+		return strings.TrimSpace(f.input.Value())
+	}
+}
+
+func (f *editorField) focus() tea.Cmd {
+	if f.kind == areaInput {
+		return f.area.Focus()
+	}
+	return f.input.Focus()
+}
+
+func (f *editorField) blur() {
+	if f.kind == areaInput {
+		f.area.Blur()
+		return
+	}
+	f.input.Blur()
+}
+
+func (f *editorField) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+
+	if f.kind == areaInput {
+		f.area, cmd = f.area.Update(msg)
+		return cmd
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok && f.kind == chipsInput {
+		switch msg.Type {
+		case tea.KeyEnter:
+			for _, v := range strings.Split(f.input.Value(), chipSep(f.isNames)) {
+				if v = strings.TrimSpace(v); v != "" && !slices.Contains(f.chips, v) {
+					f.chips = append(f.chips, v)
+				}
+			}
+			f.input.Reset()
+			return nil
+		case tea.KeyBackspace:
+			if f.input.Value() == "" && len(f.chips) > 0 {
+				f.chips = f.chips[:len(f.chips)-1]
+				return nil
+			}
+		}
+	}
+
+	f.input, cmd = f.input.Update(msg)
+	if f.err != nil {
+		f.validate()
+	}
+	return cmd
+}
+
+func chipSep(isNames bool) string {
+	if isNames {
+		return "&"
+	}
+	return ","
+}
+
+func (f *editorField) validate() error {
+	f.err = nil
+	val := f.value()
+	if val == "" {
+		return nil
+	}
+
+	switch f.kind {
+	case numberInput:
+		n, err := strconv.ParseFloat(val, 64)
+		switch {
+		case err != nil:
+			f.err = fmt.Errorf("%v is not a number", val)
+		case n < 0:
+			f.err = fmt.Errorf("%v can't be negative", f.label)
+		case f.label == "rating" && n > 10:
+			f.err = fmt.Errorf("rating is between 0 and 10")
+		}
+	case dateInput:
+		f.err = fmt.Errorf("%v is not a date, use yyyy-mm-dd", val)
+		for _, layout := range dateFormats {
+			if _, err := time.Parse(layout, val); err == nil {
+				f.err = nil
+				break
+			}
+		}
+	}
+
+	if f.err == nil && f.check != nil {
+		f.err = f.check(val)
+	}
+
+	return f.err
+}
+
+func (f *editorField) view() string {
+	switch f.kind {
+	case areaInput:
+		return f.area.View()
+	case chipsInput:
+		chip := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(Theme.Black)).
+			Background(lipgloss.Color(Theme.Cyan)).
+			Padding(0, 1).
+			MarginRight(1)
+		var chips []string
+		for _, c := range f.chips {
+			chips = append(chips, chip.Render(c))
+		}
+		return lipgloss.JoinHorizontal(lipgloss.Top, chips...) + f.input.View()
+	default:
+		return f.input.View()
+	}
+}
+
+func editorKeyMap() KeyMap {
+	return KeyMap{
+		"Quit": key.NewBinding(
+			key.WithKeys("ctrl+c", "esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		"Save": key.NewBinding(
+			key.WithKeys("ctrl+s"),
+			key.WithHelp("ctrl+s", "save"),
+		),
+		"NextField": key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "next field"),
+		),
+		"PrevField": key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("shift+tab", "prev field"),
+		),
+	}
+}
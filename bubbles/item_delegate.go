@@ -14,16 +14,23 @@ import (
 type item struct {
 	title    string
 	id       string
+	filter   string
 	marked   string
 	unmarked string
 	selected bool
 }
 
-func (i item) Title() string       { return i.title }
-func (i item) IsSelected() bool    { return i.selected }
-func (i item) ID() string          { return i.id }
-func (i item) FilterValue() string { return i.title }
-func (i *item) ToggleSelected()    { i.selected = !i.selected }
+func (i item) Title() string    { return i.title }
+func (i item) IsSelected() bool { return i.selected }
+func (i item) ID() string       { return i.id }
+func (i *item) ToggleSelected() { i.selected = !i.selected }
+
+func (i item) FilterValue() string {
+	if i.filter != "" {
+		return i.filter
+	}
+	return i.title
+}
 
 type itemDelegate struct {
 	MultiSelect bool
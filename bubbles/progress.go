@@ -0,0 +1,142 @@
+package bubbles
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type Progress struct {
+	model    progress.Model
+	program  *tea.Program
+	keyMap   KeyMap
+	title    string
+	total    int
+	done     int
+	failed   int
+	cancel   func()
+	finished chan struct{}
+	mtx      sync.Mutex
+	running  bool
+}
+
+func NewProgress(title string, total int) *Progress {
+	width := TermWidth() - 4
+	if width <= 0 {
+		width = 40
+	}
+	bar := progress.New(
+		progress.WithGradient(Theme.Cyan, Theme.Pink),
+		progress.WithWidth(width),
+	)
+	return &Progress{
+		model:    bar,
+		title:    title,
+		total:    total,
+		keyMap:   DefaultKeyMap(),
+		finished: make(chan struct{}),
+	}
+}
+
+// OnCancel is called when the progress bar is interrupted with ctrl+c, the
+// bar keeps running until Done so the caller can wind down its work.
+func (p *Progress) OnCancel(cancel func()) *Progress {
+	p.cancel = cancel
+	return p
+}
+
+func (p *Progress) Start() *Progress {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.program = tea.NewProgram(p, tea.WithOutput(os.Stderr))
+	p.running = true
+	go func() {
+		if err := p.program.Start(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		close(p.finished)
+	}()
+	return p
+}
+
+// Inc marks one item as finished, a non-nil err counts it as failed.
+func (p *Progress) Inc(err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.running {
+		p.program.Send(progressMsg{failed: err != nil})
+	}
+}
+
+// Done stops the progress bar and waits for the terminal to be restored.
+func (p *Progress) Done() {
+	p.mtx.Lock()
+	if !p.running {
+		p.mtx.Unlock()
+		return
+	}
+	p.running = false
+	p.program.Send(progressDoneMsg{})
+	p.mtx.Unlock()
+
+	<-p.finished
+}
+
+type progressMsg struct {
+	failed bool
+}
+
+type progressDoneMsg struct{}
+
+func (p *Progress) Init() tea.Cmd {
+	return nil
+}
+
+func (p *Progress) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if key.Matches(msg, p.keyMap["Quit"]) && p.cancel != nil {
+			p.cancel()
+		}
+	case tea.WindowSizeMsg:
+		p.model.Width = msg.Width - 4
+	case progressMsg:
+		p.done++
+		if msg.failed {
+			p.failed++
+		}
+		if p.total > 0 {
+			return p, p.model.SetPercent(float64(p.done) / float64(p.total))
+		}
+	case progressDoneMsg:
+		return p, tea.Quit
+	case progress.FrameMsg:
+		model, cmd := p.model.Update(msg)
+		p.model = model.(progress.Model)
+		return p, cmd
+	}
+	return p, nil
+}
+
+func (p *Progress) View() string {
+	status := fmt.Sprintf("%d/%d", p.done, p.total)
+	if p.failed > 0 {
+		status = status + lipgloss.NewStyle().
+			Foreground(lipgloss.Color(Theme.Red)).
+			Render(fmt.Sprintf(" (%d failed)", p.failed))
+	}
+
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(Theme.Purple)).
+		Padding(0, 2).
+		Render(p.title)
+
+	return title + status + "\n  " + p.model.View() + "\n"
+}
@@ -2,45 +2,133 @@ package bubbles
 
 import (
 	"log"
+	"strconv"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/exp/slices"
 )
 
+// Choice is one option of a prompt. Columns are shown in the header bar and
+// Preview, rendered as markdown, next to the list while the choice is
+// highlighted.
+type Choice struct {
+	ID      string
+	Title   string
+	Columns []string
+	Preview string
+}
+
 type Prompt struct {
-	model  list.Model
-	list   *listModel
-	data   map[string]string
-	keyMap KeyMap
-	Choice string
+	model   list.Model
+	list    *listModel
+	header  *headerBar
+	preview viewport.Model
+	choices []Choice
+	keyMap  KeyMap
+	current int
+	Choice  string
+	Choices []string
 }
 
+// NewPrompt lists the keys of items in sorted order, the value of the chosen
+// key is returned.
 func NewPrompt(title string, items map[string]string) *Prompt {
-	l := NewList().
-		SetTitle(title).
-		SetHeight(TermHeight() - 2).
-		SetWidth(TermWidth()).
-		ShowHelp()
+	var keys []string
+	for key := range items {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var choices []Choice
+	for _, key := range keys {
+		choices = append(choices, Choice{ID: items[key], Title: key})
+	}
+	return NewChoicePrompt(title, choices)
+}
+
+// NewChoicePrompt lists the choices in the order given.
+func NewChoicePrompt(title string, choices []Choice) *Prompt {
+	width, height := TermSize()
 
-	for key, _ := range items {
-		l.AppendItem(item{title: key, id: key, selected: false})
+	p := &Prompt{
+		choices: choices,
+		keyMap:  DefaultKeyMap(),
+		current: -1,
 	}
 
-	return &Prompt{
-		model:  l.Model(),
-		list:   l,
-		data:   items,
-		keyMap: DefaultKeyMap(),
+	if p.hasColumns() {
+		p.header = newHeader()
+		height = height - 1
 	}
+
+	if p.hasPreview() {
+		width = width / 2
+		p.preview = viewport.New(width, height-2)
+	}
+
+	p.list = NewList().
+		SetTitle(title).
+		SetHeight(height - 2).
+		SetWidth(width).
+		ShowHelp()
+
+	return p
 }
 
+// Multi lets more than one choice be toggled with space.
+func (m *Prompt) Multi() *Prompt {
+	m.list.Multi()
+	return m
+}
+
+// Choose returns the ID of the chosen item, or an empty string if the
+// prompt was quit.
 func (m *Prompt) Choose() string {
+	m.run()
+	return m.Choice
+}
+
+// ChooseMany returns the IDs of the toggled items in list order, or the
+// highlighted one if none were toggled.
+func (m *Prompt) ChooseMany() []string {
+	m.run()
+	return m.Choices
+}
+
+func (m *Prompt) run() {
+	for i, c := range m.choices {
+		m.list.AppendItem(item{title: c.Title, id: strconv.Itoa(i)})
+	}
+	m.model = m.list.Model()
+	m.highlight()
+
 	p := tea.NewProgram(m)
 	if err := p.Start(); err != nil {
 		log.Fatal(err)
 	}
-	return m.Choice
+}
+
+func (m *Prompt) hasColumns() bool {
+	for _, c := range m.choices {
+		if len(c.Columns) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Prompt) hasPreview() bool {
+	for _, c := range m.choices {
+		if c.Preview != "" {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *Prompt) Init() tea.Cmd {
@@ -55,6 +143,9 @@ func (m *Prompt) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.model.FilterState() == list.Filtering {
+			break
+		}
 		switch {
 		case key.Matches(msg, m.keyMap["Enter"]):
 			cmds = append(cmds, m.selectListItem())
@@ -65,27 +156,77 @@ func (m *Prompt) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case tea.WindowSizeMsg:
 	case selectedListItemMsg:
-		m.Choice = m.data[string(msg)]
+		for _, idx := range msg {
+			m.Choices = append(m.Choices, m.choices[idx].ID)
+		}
+		if len(m.Choices) > 0 {
+			m.Choice = m.Choices[0]
+		}
 		cmds = append(cmds, tea.Quit)
 	}
 
 	m.model, cmd = m.model.Update(msg)
 	cmds = append(cmds, cmd)
+	m.highlight()
 
 	return m, tea.Batch(cmds...)
 }
 
+// highlight updates the header and preview for the highlighted choice.
+func (m *Prompt) highlight() {
+	i, ok := m.model.SelectedItem().(item)
+	if !ok {
+		return
+	}
+	idx, _ := strconv.Atoi(i.ID())
+	if idx == m.current {
+		return
+	}
+	m.current = idx
+	choice := m.choices[idx]
+
+	if m.header != nil {
+		cols := make([]string, 4)
+		copy(cols, choice.Columns)
+		if len(choice.Columns) > 4 {
+			cols[3] = strings.Join(choice.Columns[3:], " • ")
+		}
+		m.header.model.SetContent(cols[0], cols[1], cols[2], cols[3])
+	}
+
+	if choice.Preview != "" {
+		m.preview.SetContent(RenderMarkdown(choice.Preview))
+		m.preview.GotoTop()
+	}
+}
+
 func (m *Prompt) View() string {
-	return m.model.View()
+	view := m.model.View()
+	if m.hasPreview() {
+		view = lipgloss.JoinHorizontal(lipgloss.Top, view, m.preview.View())
+	}
+	if m.header != nil {
+		view = lipgloss.JoinVertical(lipgloss.Left, m.header.model.View(), view)
+	}
+	return view
 }
 
-type selectedListItemMsg string
+type selectedListItemMsg []int
 
 func (m *Prompt) selectListItem() tea.Cmd {
 	return func() tea.Msg {
 		var msg selectedListItemMsg
-		if i, ok := m.model.SelectedItem().(item); ok {
-			msg = selectedListItemMsg(i.ID())
+		for _, li := range m.model.Items() {
+			if i, ok := li.(item); ok && i.IsSelected() {
+				idx, _ := strconv.Atoi(i.ID())
+				msg = append(msg, idx)
+			}
+		}
+		if len(msg) == 0 {
+			if i, ok := m.model.SelectedItem().(item); ok {
+				idx, _ := strconv.Atoi(i.ID())
+				msg = append(msg, idx)
+			}
 		}
 		return msg
 	}
@@ -0,0 +1,274 @@
+package calibredb
+
+import (
+	"container/list"
+	"context"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	defaultCacheEntries = 500
+	defaultCacheBytes   = 64 << 20
+	maxCachedStmts      = 1000
+	modifiedInterval    = time.Second
+)
+
+// Cache keeps json responses, keyed by normalized request url, and rendered
+// sql statements in memory. Responses are dropped when metadata.db changes
+// on disk or the newest last_modified of the books advances.
+type Cache struct {
+	mtx        sync.Mutex
+	disabled   bool
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	entries    map[string]*list.Element
+	lru        *list.List
+	stmts      map[string]string
+	gen        uint64
+	modified   string
+	checked    time.Time
+	stats      CacheStats
+	watcher    *fsnotify.Watcher
+}
+
+// CacheStats counts the cache's lookups since the library was opened.
+type CacheStats struct {
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Evictions     uint64 `json:"evictions"`
+	Invalidations uint64 `json:"invalidations"`
+	Entries       int    `json:"entries"`
+	Bytes         int64  `json:"bytes"`
+}
+
+type cacheEntry struct {
+	key  string
+	body []byte
+}
+
+func newCache() *Cache {
+	return &Cache{
+		maxEntries: defaultCacheEntries,
+		maxBytes:   defaultCacheBytes,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		stmts:      make(map[string]string),
+	}
+}
+
+func (lib *Lib) Cache() *Cache {
+	return lib.cache
+}
+
+func (c *Cache) SetMaxEntries(n int) *Cache {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.maxEntries = n
+	c.evict()
+	return c
+}
+
+func (c *Cache) SetMaxBytes(n int64) *Cache {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.maxBytes = n
+	c.evict()
+	return c
+}
+
+func (c *Cache) Disable() *Cache {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.disabled = true
+	c.clear()
+	c.stmts = make(map[string]string)
+	return c
+}
+
+func (c *Cache) Stats() CacheStats {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// Clear drops every cached response.
+func (c *Cache) Clear() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.clear()
+}
+
+func (c *Cache) clear() {
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+	c.gen++
+	c.stats.Invalidations++
+}
+
+// get returns the cached response for key and the cache generation, which
+// set needs so responses started before an invalidation aren't stored.
+func (c *Cache) get(key string) ([]byte, uint64, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.disabled {
+		return nil, c.gen, false
+	}
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		c.stats.Hits++
+		return el.Value.(*cacheEntry).body, c.gen, true
+	}
+	c.stats.Misses++
+	return nil, c.gen, false
+}
+
+func (c *Cache) set(key string, gen uint64, body []byte) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.disabled || gen != c.gen || int64(len(body)) > c.maxBytes {
+		return
+	}
+	if el, ok := c.entries[key]; ok {
+		c.bytes -= int64(len(el.Value.(*cacheEntry).body))
+		c.lru.Remove(el)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, body: body})
+	c.bytes += int64(len(body))
+	c.evict()
+}
+
+// evict drops the least recently used responses until the cache is within
+// its limits.
+func (c *Cache) evict() {
+	for c.lru.Len() > 0 && (c.lru.Len() > c.maxEntries || c.bytes > c.maxBytes) {
+		el := c.lru.Back()
+		entry := el.Value.(*cacheEntry)
+		c.lru.Remove(el)
+		delete(c.entries, entry.key)
+		c.bytes -= int64(len(entry.body))
+		c.stats.Evictions++
+	}
+}
+
+// stmt returns the cached statement for key, rendering it if it isn't
+// cached. Statements only depend on the library's fields so they're kept
+// when responses are invalidated.
+func (c *Cache) stmt(key string, render func() string) string {
+	c.mtx.Lock()
+	if s, ok := c.stmts[key]; ok {
+		c.mtx.Unlock()
+		return s
+	}
+	c.mtx.Unlock()
+
+	s := render()
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.disabled {
+		return s
+	}
+	if len(c.stmts) >= maxCachedStmts {
+		c.stmts = make(map[string]string)
+	}
+	c.stmts[key] = s
+	return s
+}
+
+// checkModified clears the cache when the newest last_modified of the books
+// changes, it's checked at most once a second.
+func (lib *Lib) checkModified(ctx context.Context) {
+	c := lib.cache
+	c.mtx.Lock()
+	if c.disabled || time.Since(c.checked) < modifiedInterval {
+		c.mtx.Unlock()
+		return
+	}
+	c.checked = time.Now()
+	c.mtx.Unlock()
+
+	var modified string
+	row := lib.db.QueryRowxContext(ctx, "SELECT lower(IFNULL(MAX(last_modified), '')) FROM books")
+	if err := row.Scan(&modified); err != nil {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.modified != "" && c.modified != modified {
+		c.clear()
+	}
+	c.modified = modified
+}
+
+// watch clears the cache and looks for changed books when metadata.db or its
+// journal is written to.
+func (lib *Lib) watch() {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("not watching %v for changes: %v\n", lib.Name, err)
+		return
+	}
+	err = w.Add(lib.Path)
+	if err != nil {
+		log.Printf("not watching %v for changes: %v\n", lib.Name, err)
+		w.Close()
+		return
+	}
+	lib.cache.watcher = w
+
+	go func() {
+		for {
+			select {
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if ev.Op == fsnotify.Chmod {
+					continue
+				}
+				switch filepath.Base(ev.Name) {
+				case "metadata.db", "metadata.db-wal", "metadata.db-journal":
+					lib.cache.Clear()
+					lib.changes.notify()
+				}
+			case _, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Close stops watching the library and closes the database.
+func (lib *Lib) Close() error {
+	if w := lib.cache.watcher; w != nil {
+		w.Close()
+	}
+	return lib.db.Close()
+}
+
+// cacheKey makes equivalent urls share a cache key, the query params are
+// sorted.
+func cacheKey(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	parsed.RawQuery = parsed.Query().Encode()
+	parsed.Path = "/" + strings.Trim(parsed.Path, "/")
+	return parsed.String()
+}
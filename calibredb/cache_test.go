@@ -0,0 +1,26 @@
+package calibredb
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCacheStats(t *testing.T) {
+	lib := testLib(t)
+
+	first := lib.Get("/books/1")
+	second := lib.Get("/books/1")
+	if !bytes.Equal(first, second) {
+		t.Error("cached response differs")
+	}
+
+	s := lib.Cache().Stats()
+	if s.Misses != 1 || s.Hits != 1 || s.Entries != 1 {
+		t.Errorf("got %+v, want 1 miss, 1 hit and 1 entry", s)
+	}
+
+	lib.Cache().Clear()
+	if s := lib.Cache().Stats(); s.Entries != 0 || s.Invalidations == 0 {
+		t.Errorf("got %+v after Clear", s)
+	}
+}
@@ -0,0 +1,184 @@
+package calibredb
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	changeBuffer   = 64
+	changeDebounce = 250 * time.Millisecond
+	changePoll     = 5 * time.Second
+)
+
+// Change is a batch of books that were added, modified or removed from a
+// library.
+type Change struct {
+	Library string   `json:"library"`
+	Type    string   `json:"type"`
+	IDs     []string `json:"ids"`
+}
+
+type changeFeed struct {
+	mtx     sync.Mutex
+	subs    map[chan Change]struct{}
+	signal  chan struct{}
+	running bool
+}
+
+func newChangeFeed() *changeFeed {
+	return &changeFeed{
+		subs:   make(map[chan Change]struct{}),
+		signal: make(chan struct{}, 1),
+	}
+}
+
+// Subscribe returns a channel of the library's changes, it's closed when ctx
+// is done. Changes are found by comparing the ids and last_modified of the
+// books when metadata.db is written to, or every few seconds.
+func (lib *Lib) Subscribe(ctx context.Context) <-chan Change {
+	f := lib.changes
+	ch := make(chan Change, changeBuffer)
+
+	f.mtx.Lock()
+	f.subs[ch] = struct{}{}
+	if !f.running {
+		f.running = true
+		go lib.watchChanges()
+	}
+	f.mtx.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		f.mtx.Lock()
+		delete(f.subs, ch)
+		close(ch)
+		f.mtx.Unlock()
+	}()
+
+	return ch
+}
+
+// notify wakes up watchChanges, it doesn't block.
+func (f *changeFeed) notify() {
+	select {
+	case f.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (f *changeFeed) broadcast(c Change) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for ch := range f.subs {
+		select {
+		case ch <- c:
+		default:
+			log.Printf("dropped %v change for a slow subscriber\n", c.Type)
+		}
+	}
+}
+
+// watchChanges diffs snapshots of the library until there are no
+// subscribers.
+func (lib *Lib) watchChanges() {
+	f := lib.changes
+	snap, _ := lib.snapshot(context.Background())
+
+	ticker := time.NewTicker(changePoll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.signal:
+			// a write usually comes with several events
+			time.Sleep(changeDebounce)
+			select {
+			case <-f.signal:
+			default:
+			}
+		case <-ticker.C:
+		}
+
+		f.mtx.Lock()
+		if len(f.subs) == 0 {
+			f.running = false
+			f.mtx.Unlock()
+			return
+		}
+		f.mtx.Unlock()
+
+		next, err := lib.snapshot(context.Background())
+		if err != nil {
+			continue
+		}
+		if snap != nil {
+			for _, c := range diffSnapshots(snap, next) {
+				c.Library = lib.Name
+				f.broadcast(c)
+			}
+		}
+		snap = next
+	}
+}
+
+// snapshot is the last_modified of each book by id.
+func (lib *Lib) snapshot(ctx context.Context) (map[string]string, error) {
+	rows, err := lib.db.QueryxContext(ctx, "SELECT id, lower(last_modified) FROM books")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snap := make(map[string]string)
+	for rows.Next() {
+		var (
+			id       int
+			modified string
+		)
+		if err := rows.Scan(&id, &modified); err != nil {
+			return nil, err
+		}
+		snap[strconv.Itoa(id)] = modified
+	}
+	return snap, rows.Err()
+}
+
+func diffSnapshots(old, next map[string]string) []Change {
+	var added, modified, removed []string
+	for id, mod := range next {
+		prev, ok := old[id]
+		switch {
+		case !ok:
+			added = append(added, id)
+		case prev != mod:
+			modified = append(modified, id)
+		}
+	}
+	for id := range old {
+		if _, ok := next[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+
+	var changes []Change
+	for _, c := range []Change{
+		{Type: "added", IDs: added},
+		{Type: "modified", IDs: modified},
+		{Type: "removed", IDs: removed},
+	} {
+		if len(c.IDs) > 0 {
+			sort.Slice(c.IDs, func(i, j int) bool {
+				a, _ := strconv.Atoi(c.IDs[i])
+				b, _ := strconv.Atoi(c.IDs[j])
+				return a < b
+			})
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
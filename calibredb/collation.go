@@ -0,0 +1,116 @@
+package calibredb
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-sqlite3"
+	"golang.org/x/exp/slices"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// collationTags are the locales with a collation on every connection, the
+// root collation is first. Locales are matched to the ones without variants
+// like de-u-co-phonebk, those have to be asked for.
+var (
+	collationTags    = collate.Supported()
+	plainCollations  = plainTags(collationTags)
+	collationMatcher = language.NewMatcher(plainCollations)
+)
+
+func plainTags(tags []language.Tag) []language.Tag {
+	var plain []language.Tag
+	for _, t := range tags {
+		if len(t.Extensions()) == 0 {
+			plain = append(plain, t)
+		}
+	}
+	return plain
+}
+
+// collatedSorts are the title, name, series and category value columns
+// sorted with the locale's collation.
+var collatedSorts = []string{
+	"sort",
+	"title",
+	"author_sort",
+	"name",
+	"value",
+	seriesSort,
+}
+
+// registerCollations registers a case insensitive unicode collation for each
+// locale, named calibre_<locale>. A connection is only used by one query at a
+// time so each collation's collator is made on first use and isn't locked.
+func registerCollations(conn *sqlite3.SQLiteConn) error {
+	for _, tag := range collationTags {
+		var (
+			t = tag
+			c *collate.Collator
+		)
+		err := conn.RegisterCollation(collationName(t), func(a, b string) int {
+			if c == nil {
+				c = collate.New(t, collate.IgnoreCase)
+			}
+			return c.CompareString(a, b)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collationName(tag language.Tag) string {
+	return "calibre_" + strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, tag.String())
+}
+
+// localeCollation is the collation of the closest locale that has one.
+func localeCollation(locale string) (string, error) {
+	if locale == "" {
+		return collationName(language.Und), nil
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", fmt.Errorf("%v is not a locale", locale)
+	}
+	if slices.IndexFunc(collationTags, func(t language.Tag) bool { return t == tag }) >= 0 {
+		return collationName(tag), nil
+	}
+	_, i, _ := collationMatcher.Match(tag)
+	return collationName(plainCollations[i]), nil
+}
+
+// SetLocale sets the locale titles, names and categories are sorted for,
+// calibre's root collation is used by default. It's meant to be called
+// before the library is queried.
+func (lib *Lib) SetLocale(locale string) error {
+	c, err := localeCollation(locale)
+	if err != nil {
+		return err
+	}
+	lib.collation = c
+	return nil
+}
+
+// collate adds the request's collation to a sort column that's text.
+func (q *query) collate(col string) string {
+	if !slices.Contains(collatedSorts, strings.TrimSpace(col)) {
+		return col
+	}
+	c := q.collation
+	if q.Request.collation != "" {
+		c = q.Request.collation
+	}
+	if c == "" {
+		c = collationName(language.Und)
+	}
+	return strings.TrimSpace(col) + " COLLATE " + c + " "
+}
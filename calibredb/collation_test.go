@@ -0,0 +1,111 @@
+package calibredb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLocaleCollation(t *testing.T) {
+	for locale, want := range map[string]string{
+		"":                "calibre_und",
+		"fr":              "calibre_fr",
+		"fr-CA":           "calibre_fr_CA",
+		"de-AT":           "calibre_de",
+		"de-u-co-phonebk": "calibre_de_u_co_phonebk",
+		"ja":              "calibre_ja",
+		"ru-RU":           "calibre_ru",
+	} {
+		got, err := localeCollation(locale)
+		if err != nil {
+			t.Errorf("%q: %v", locale, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q collates with %v, want %v", locale, got, want)
+		}
+	}
+
+	if _, err := localeCollation("not a locale"); err == nil {
+		t.Error("an invalid locale has a collation")
+	}
+}
+
+// sortedTitles gets the titles of the books with ids sorted by field for the
+// locale.
+func sortedTitles(t *testing.T, lib *Lib, field, locale string, ids ...int) []string {
+	t.Helper()
+	var s []string
+	for _, id := range ids {
+		s = append(s, fmt.Sprint(id))
+	}
+	v := url.Values{}
+	v.Set("ids", strings.Join(s, ","))
+	v.Set("sort", field)
+	if locale != "" {
+		v.Set("locale", locale)
+	}
+
+	var resp testResponse
+	if err := json.Unmarshal(lib.Get("/books?"+v.Encode()), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) > 0 {
+		t.Fatalf("%v: %v", v.Encode(), resp.Errors)
+	}
+	return resp.titles()
+}
+
+// the international books in testdata/metadata.sql
+const (
+	eclair = 21 + iota
+	eagle
+	ecume
+	zebreAccent
+	arger
+	apfel
+	oel
+	ozean
+	tokyo
+	asahi
+	camera
+	yunost
+	azbuka
+	yolka
+	quoted
+	zebra
+)
+
+func TestCollatedTitles(t *testing.T) {
+	lib := testLib(t)
+
+	for _, test := range []struct {
+		locale string
+		ids    []int
+		want   string
+	}{
+		{"fr", []int{zebreAccent, ecume, zebra, eclair, eagle}, "Eagle Éclair écume zebra Zèbre"},
+		{"de", []int{ozean, oel, arger, apfel}, "Apfel Ärger Öl Ozean"},
+		{"de-u-co-phonebk", []int{ozean, oel, arger, apfel}, "Ärger Apfel Öl Ozean"},
+		{"ja", []int{tokyo, camera, asahi}, "あさひ カメラ 東京物語"},
+		{"ru", []int{yunost, yolka, azbuka}, "Азбука ёлка Юность"},
+	} {
+		got := strings.Join(sortedTitles(t, lib, "title", test.locale, test.ids...), " ")
+		if got != test.want {
+			t.Errorf("%v: titles sorted as %v, want %v", test.locale, got, test.want)
+		}
+	}
+}
+
+func TestCollatedSeries(t *testing.T) {
+	lib := testLib(t)
+
+	// Épopée sorts before Saga, then the Saga books by position
+	got := strings.Join(sortedTitles(t, lib, "series", "fr", 12, eclair, 6, arger, 15), ", ")
+	want := "Ärger, Éclair, Book 015, Book 006, Book 012"
+	if got != want {
+		t.Errorf("books sorted by series are %v, want %v", got, want)
+	}
+}
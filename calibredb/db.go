@@ -2,17 +2,20 @@ package calibredb
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
 	"log"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
-	"sync"
 	"text/template"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 	"golang.org/x/exp/slices"
 )
 
@@ -26,10 +29,25 @@ type Lib struct {
 	Preferences    *Preferences
 	CustCols       []map[string]string
 	db             *sqlx.DB
-	Request        *request
-	response       *response
-	mtx            sync.Mutex
 	bookTmpl       *template.Template
+	hiddenCats     []string
+	displayFields  []displayField
+	savedSearches  map[string]string
+	vlDefs         map[string]string
+	virtualLibs    map[string]string
+	cache          *Cache
+	changes        *changeFeed
+	collation      string
+}
+
+// query is the state of a single Get call. It's what the sql templates are
+// rendered with, so a Lib is never written to after NewLib and can be used
+// concurrently.
+type query struct {
+	*Lib
+	ctx      context.Context
+	Request  *request
+	response *response
 }
 
 //go:embed sql/*
@@ -38,13 +56,17 @@ var sqlTmpl embed.FS
 func NewLib(path string) *Lib {
 	lib := Lib{}
 	lib.Path = path
-	lib.dbPath = "file:" + filepath.Join(path, "metadata.db") + "?cache=shared&mode=ro"
+	lib.dbPath = "file:" + filepath.Join(path, "metadata.db") + "?mode=ro"
 	lib.Name = filepath.Base(path)
 	lib.db = lib.connectDB()
 	lib.Fields = newLibFields(lib.Name)
 	lib.getPreferences()
 	lib.getCustCols()
+	lib.getVirtualLibs()
 	lib.bookTmpl = template.Must(template.New("book").Funcs(bookTmplFuncs).ParseFS(sqlTmpl, "sql/*"))
+	lib.cache = newCache()
+	lib.changes = newChangeFeed()
+	lib.watch()
 
 	//fmt.Printf("field meta %+v\n", lib.fieldMeta)
 	return &lib
@@ -61,55 +83,101 @@ var (
 	}
 )
 
+// Get answers an api url with a json response.
 func (lib *Lib) Get(u string) []byte {
-	lib.mtx.Lock()
-	defer lib.mtx.Unlock()
-	//fmt.Printf("%+v\n", u)
-	lib.response = newResponse()
+	return lib.GetContext(context.Background(), u)
+}
+
+// GetContext is Get with the queries run with ctx, when ctx is done the
+// response has a timeout or canceled error. Responses without errors are
+// cached until the library changes.
+func (lib *Lib) GetContext(ctx context.Context, u string) []byte {
+	lib.checkModified(ctx)
+
+	key := cacheKey(u)
+	resp, gen, ok := lib.cache.get(key)
+	if ok {
+		return resp
+	}
 
-	var err error
-	lib.Request, err = lib.newRequest(u)
+	q := &query{Lib: lib, ctx: ctx, response: newResponse()}
+	resp = q.get(u)
+	if len(q.response.Errors) == 0 {
+		lib.cache.set(key, gen, resp)
+	}
+	return resp
+}
+
+func (q *query) get(u string) []byte {
+	err := q.newRequest(u)
 	if err != nil {
-		lib.response.addErr(err)
-		return lib.response.json()
+		q.response.addErr(err)
+		return q.response.json()
 	}
 
-	//fmt.Printf("%+v\n", lib.Request)
-	lib.numberOfItems()
+	err = q.numberOfItems()
+	if err != nil {
+		q.response.addErr(queryErr(err))
+		return q.response.json()
+	}
 
-	lib.setResponseURL()
+	q.setResponseURL()
 
-	lib.setResponseMeta()
+	q.setResponseMeta()
 
-	if !lib.Request.allItems {
-		if lib.response.numberOfItems > lib.Request.itemsPerPage {
-			if lib.Request.bookQuery {
-				lib.calculatePagination()
+	if !q.Request.allItems {
+		if q.response.numberOfItems > q.Request.itemsPerPage {
+			if q.Request.bookQuery {
+				q.calculatePagination()
 			}
 		}
 	}
 
 	var data any
-	if lib.Request.cat == "preferences" {
-		if lib.Request.HasFields {
-			data = lib.GetPref("field_meta")
+	switch {
+	case q.Request.cat == "preferences":
+		if q.Request.HasFields {
+			data, err = q.getPref(q.ctx, "field_meta", q.Request.Fields)
 		} else {
-			data = lib.GetPreferences()
+			data, err = q.preferences(q.ctx)
 		}
-		//return lib.GetPreferences()
-	} else {
-		data = lib.queryDB()
+	case q.Request.cat == "searches" && !q.Request.bookQuery:
+		data, err = q.savedSearchList()
+	case q.Request.cat == "categories":
+		data = q.categoryList()
+	default:
+		data, err = q.queryDB()
+		if q.Request.detail && q.Request.display && !q.Request.HasFields {
+			data = q.displayBooks(data)
+		}
+	}
+	if err != nil {
+		q.response.addErr(queryErr(err))
+		return q.response.json()
 	}
 
-	lib.setResponseData(data)
-	json := lib.response.json()
-	return json
+	q.setResponseData(data)
+	return q.response.json()
+}
+
+// queryErr is the response error for a failed query.
+func queryErr(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("504 Gateway Timeout:the query took too long")
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("499 Client Closed Request:the query was canceled")
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("404 Not Found:nothing was found")
+	}
+	return fmt.Errorf("500 Internal Server Error:%v", err)
 }
 
 func (lib *Lib) validEndpoint(point string) bool {
 	end := lib.Categories()
-	end = append(end, "preferences", "customColumns", "books")
-	return slices.Contains(end, point)
+	end = append(end, "preferences", "customColumns", "books", "searches", "categories")
+	// custom categories are requested without their #
+	return slices.Contains(end, point) || slices.Contains(end, "#"+point)
 }
 
 func (lib *Lib) Categories() []string {
@@ -131,25 +199,63 @@ func (lib *Lib) Categories() []string {
 	return fields
 }
 
+// sqliteDriver is sqlite3 with the REGEXP function that searches use.
+const sqliteDriver = "sqlite3_calibre"
+
+func init() {
+	sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("regexp", regexpMatch, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("composite", compositeFunc, true); err != nil {
+				return err
+			}
+			return registerCollations(conn)
+		},
+	})
+}
+
+// maxReaders is the size of the connection pool, the database is opened
+// read only so readers don't block each other.
+var maxReaders = runtime.NumCPU() * 2
+
 func (lib *Lib) connectDB() *sqlx.DB {
-	database, err := sqlx.Open("sqlite3", lib.dbPath)
+	database, err := sqlx.Open(sqliteDriver, lib.dbPath)
 	if err != nil {
 		log.Fatal(err)
 	}
+	database.SetMaxOpenConns(maxReaders)
+	database.SetMaxIdleConns(maxReaders)
 	return database
 }
 
+type dbField struct {
+	Column     string
+	Table      string
+	LinkColumn string
+}
+
+// GetField returns the table and columns of a category field.
+func (lib *Lib) GetField(label string) dbField {
+	return dbField{
+		Column:     lib.getFieldMeta(label, "column"),
+		Table:      lib.getFieldMeta(label, "table"),
+		LinkColumn: lib.getFieldMeta(label, "link_column"),
+	}
+}
+
 type dbData []map[string]map[string]string
 
-func (lib *Lib) queryDB() any {
+func (q *query) queryDB() (any, error) {
 	var (
-		query, args = lib.queryStmt()
+		query, args = q.queryStmt()
 	)
 	//fmt.Println(query)
 
-	rows, err := lib.db.Queryx(query, args...)
+	rows, err := q.db.QueryxContext(q.ctx, query, args...)
 	if err != nil {
-		log.Fatal(query)
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -157,131 +263,237 @@ func (lib *Lib) queryDB() any {
 	for rows.Next() {
 		m := make(map[string]interface{})
 		if err := rows.MapScan(m); err != nil {
-			fmt.Errorf("Association %s", err)
+			return nil, err
 		}
 		data = append(data, convertFields(m))
-		//lib.response.Data = append(lib.response.Data, convertFields(m))
+		//q.response.Data = append(q.response.Data, convertFields(m))
 	}
-	return data
-	//lib.response.Data = data
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return data, nil
+	//q.response.Data = data
 }
 
-func (lib *Lib) numberOfItems() {
+func (q *query) numberOfItems() error {
 	var (
 		stmt  strings.Builder
 		total int
 	)
 
-	switch len(lib.Request.itemIDs) {
-	case 0:
+	grouped := q.Request.cat == "formats" || q.Request.cat == "identifiers"
+	vl := q.VirtualLibrary() != "" && (q.Request.bookQuery || !grouped)
+	table := GetFieldMeta(q, q.Request.cat, "table")
+	if q.Request.bookQuery {
+		table = "books"
+	}
+	switch {
+	case q.Request.cat == "searches" && !q.Request.bookQuery:
+		q.response.numberOfItems = len(q.savedSearches)
+	case q.Request.cat == "categories":
+		q.response.numberOfItems = len(q.categoryList())
+	case table == "":
+		// endpoints like customColumns aren't tables, they aren't counted
+	case vl || q.Request.searchCond != "":
 		stmt.WriteString("SELECT COUNT(*) FROM ")
-		stmt.WriteString(lib.Request.cat)
-		row := lib.db.QueryRowx(stmt.String())
-		row.Scan(&total)
-		lib.response.numberOfItems = total
+		stmt.WriteString(table)
+		stmt.WriteString(q.where())
+		count, args := stmt.String(), []interface{}{}
+		if len(q.Request.itemIDs) > 0 {
+			var err error
+			count, args, err = sqlx.In(count, q.Request.itemIDs)
+			if err != nil {
+				return err
+			}
+		}
+		row := q.db.QueryRowxContext(q.ctx, count, args...)
+		if err := row.Scan(&total); err != nil {
+			return err
+		}
+		q.response.numberOfItems = total
+	case len(q.Request.itemIDs) == 0:
+		// formats and identifiers are listed grouped by value
+		switch q.Request.cat {
+		case "formats":
+			stmt.WriteString("SELECT COUNT(DISTINCT format) FROM ")
+		case "identifiers":
+			stmt.WriteString("SELECT COUNT(DISTINCT val) FROM ")
+		default:
+			stmt.WriteString("SELECT COUNT(*) FROM ")
+		}
+		stmt.WriteString(table)
+		row := q.db.QueryRowxContext(q.ctx, stmt.String())
+		if err := row.Scan(&total); err != nil {
+			return err
+		}
+		q.response.numberOfItems = total
 	default:
-		lib.response.numberOfItems = len(lib.Request.itemIDs)
+		q.response.numberOfItems = len(q.Request.itemIDs)
 	}
+	return q.ctx.Err()
 }
 
-func (lib *Lib) renderSqlTmpl(name string) string {
-	var buf bytes.Buffer
-	err := lib.bookTmpl.ExecuteTemplate(&buf, name, lib)
-	if err != nil {
-		log.Println("executing template:", err)
+// VirtualLibrary is the sql selecting the ids of the books in the request's
+// virtual library, it's empty if the request doesn't have one.
+func (q *query) VirtualLibrary() string {
+	if q.Request == nil || q.Request.VL == "" {
+		return ""
 	}
+	return q.virtualLibs[q.Request.VL]
+}
+
+// where restricts a query to the requested ids, saved search and virtual
+// library.
+func (q *query) where() string {
+	var conds []string
+	if len(q.Request.itemIDs) > 0 {
+		if q.Request.bookQuery {
+			conds = append(conds, "books.id IN (?)")
+		} else {
+			conds = append(conds, "id IN (?)")
+		}
+	}
+
+	if q.Request.searchCond != "" {
+		conds = append(conds, "("+q.Request.searchCond+")")
+	}
+
+	if vl := q.VirtualLibrary(); vl != "" {
+		switch {
+		case q.Request.bookQuery:
+			conds = append(conds, "books.id IN ("+vl+")")
+		case q.Request.cat == "formats" || q.Request.cat == "identifiers":
+			// the category template restricts these
+		default:
+			table := GetFieldMeta(q, q.Request.cat, "table")
+			link := GetFieldMeta(q, q.Request.cat, "link_column")
+			if table != "" && link != "" {
+				conds = append(conds, fmt.Sprintf("id IN (SELECT %s FROM books_%s_link WHERE book IN (%s))", link, table, vl))
+			}
+		}
+	}
+
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ") + " "
+}
 
-	return buf.String()
+// renderSqlTmpl renders the named sql template, the templates only use the
+// category, id and virtual library of the request so that's what they're
+// cached by.
+func (q *query) renderSqlTmpl(name string) string {
+	key := strings.Join([]string{name, q.Request.CatLabel, q.Request.PathID, q.Request.VL}, "/")
+	return q.cache.stmt(key, func() string {
+		var buf bytes.Buffer
+		err := q.bookTmpl.ExecuteTemplate(&buf, name, q)
+		if err != nil {
+			log.Println("executing template:", err)
+		}
+		return buf.String()
+	})
 }
 
-func (lib *Lib) queryStmt() (string, []interface{}) {
+func (q *query) queryStmt() (string, []interface{}) {
 	var args []interface{}
-	if lib.Request.bookQuery {
-		return lib.bookStmt()
+	if q.Request.bookQuery {
+		return q.bookStmt()
 	} else {
-		switch table := lib.Request.cat; table {
+		switch table := q.Request.cat; table {
 		case "preferences":
 			return prefSql, args
 		case "customColumns":
 			return customColumnsSql, args
 		default:
-			return lib.relationStmt(table)
+			return q.relationStmt(table)
 		}
 	}
 }
 
-func (lib *Lib) booksInCatStmt(table string, id string) string {
+func (q *query) booksInCatStmt(table string, id string) (string, error) {
 	var (
 		ids   string
 		value string
 	)
 
-	lib.Request.PathID = id
-	lib.Request.CatLabel = table
+	q.Request.PathID = id
+	q.Request.CatLabel = table
 
-	row := lib.db.QueryRowx(lib.renderSqlTmpl("booksInCategory"))
-	row.Scan(&value, &ids)
+	row := q.db.QueryRowxContext(q.ctx, q.renderSqlTmpl("booksInCategory"))
+	if err := row.Scan(&value, &ids); err != nil {
+		return "", err
+	}
 
-	lib.response.booksInCat = value
+	q.response.booksInCat = value
 
-	return ids
+	return ids, q.ctx.Err()
 }
 
-func (lib *Lib) bookStmt() (string, []interface{}) {
-	if !lib.Request.HasFields {
-		lib.Request.Fields = lib.AllFields()
+func (q *query) bookStmt() (string, []interface{}) {
+	if !q.Request.HasFields {
+		q.Request.Fields = q.AllFields()
 	}
 
-	return lib.filterQuery(lib.renderSqlTmpl("book"))
+	return q.filterQuery(q.renderSqlTmpl("book"))
 }
 
-func (lib *Lib) custStmt() {
-	println(lib.renderSqlTmpl("custCol"))
+func (q *query) custStmt() {
+	println(q.renderSqlTmpl("custCol"))
 }
 
 // Build association Queries
-func (lib *Lib) relationStmt(table string) (string, []interface{}) {
-	lib.Request.isSorted = true
-	//lib.Request.sort = lib.GetField(table).Column
-	field := GetTableColumns(table, lib.Name)
-	lib.Request.sort = field["value"]
-	if table == "formats" {
-		lib.Request.sort = "format"
+func (q *query) relationStmt(table string) (string, []interface{}) {
+	q.Request.isSorted = true
+	//q.Request.sort = lib.GetField(table).Column
+	field := GetTableColumns(table, q.Name)
+	q.Request.sort = field["value"]
+	switch {
+	case table == "formats":
+		q.Request.sort = "format"
+	case q.Request.sort == "":
+		// custom categories keep their names in value
+		q.Request.sort = "value"
 	}
 
-	return lib.filterQuery(lib.renderSqlTmpl("category"))
+	return q.filterQuery(q.renderSqlTmpl("category"))
 }
 
-func (lib *Lib) filterQuery(q string) (string, []interface{}) {
+func (q *query) filterQuery(sql string) (string, []interface{}) {
 	var (
 		stmt strings.Builder
 	)
 
-	stmt.WriteString(q)
+	stmt.WriteString(sql)
 	stmt.WriteString("\n")
 
-	if len(lib.Request.itemIDs) > 0 {
-		if lib.Request.bookQuery {
-			stmt.WriteString(" WHERE books.id IN (?) ")
-		} else {
-			stmt.WriteString(" WHERE id IN (?) ")
-		}
+	if where := q.where(); where != "" {
+		stmt.WriteString(where)
 		stmt.WriteString("\n")
 	}
 
 	stmt.WriteString(" ORDER BY ")
-	if lib.Request.isSorted {
-		if lib.Request.bookQuery {
-			stmt.WriteString(BookSortField(lib.Request.sort))
+	if q.Request.isSorted {
+		if col := q.sortCustCol(); col != nil {
+			stmt.WriteString(q.custColSort(col))
+		} else if q.Request.bookQuery {
+			sort := BookSortField(q.Request.sort)
+			stmt.WriteString(q.collate(sort))
+			// books in the same series are in series order
+			if sort == seriesSort {
+				if q.Request.desc {
+					stmt.WriteString(" DESC")
+				}
+				stmt.WriteString(", books.series_index ")
+			}
 		} else {
-			stmt.WriteString(lib.Request.sort)
+			stmt.WriteString(q.collate(q.Request.sort))
 		}
-	} else if !lib.Request.isSorted {
-		if lib.Request.isCustom {
-			if lib.Request.bookQuery {
+	} else if !q.Request.isSorted {
+		if q.Request.isCustom {
+			if q.Request.bookQuery {
 				stmt.WriteString("timestamp ")
 			} else {
-				stmt.WriteString("value ")
+				stmt.WriteString(q.collate("value"))
 			}
 		} else {
 			stmt.WriteString("timestamp ")
@@ -290,18 +502,18 @@ func (lib *Lib) filterQuery(q string) (string, []interface{}) {
 
 	stmt.WriteString("\n")
 
-	if lib.Request.desc {
+	if q.Request.desc {
 		stmt.WriteString(" DESC ")
 		stmt.WriteString("\n")
 	}
 
-	if lib.Request.itemsPerPage != 0 {
+	if q.Request.itemsPerPage != 0 {
 		stmt.WriteString(" LIMIT ")
-		stmt.WriteString(strconv.Itoa(lib.Request.itemsPerPage))
+		stmt.WriteString(strconv.Itoa(q.Request.itemsPerPage))
 		stmt.WriteString("\n")
 	}
 
-	if offset := lib.Request.calculateOffset(); offset != 0 {
+	if offset := q.Request.calculateOffset(); offset != 0 {
 		stmt.WriteString(" OFFSET ")
 		stmt.WriteString(strconv.Itoa(offset))
 		stmt.WriteString("\n")
@@ -313,10 +525,10 @@ func (lib *Lib) filterQuery(q string) (string, []interface{}) {
 		query string
 		args  []interface{}
 	)
-	switch len(lib.Request.itemIDs) > 0 {
+	switch len(q.Request.itemIDs) > 0 {
 	case true:
 		var err error
-		query, args, err = sqlx.In(stmt.String(), lib.Request.itemIDs)
+		query, args, err = sqlx.In(stmt.String(), q.Request.itemIDs)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -328,8 +540,70 @@ func (lib *Lib) filterQuery(q string) (string, []interface{}) {
 	return query, args
 }
 
+// sortCustCol is the custom column books are sorted by, if any.
+func (q *query) sortCustCol() map[string]string {
+	if !q.Request.bookQuery || !strings.HasPrefix(q.Request.sort, "#") {
+		return nil
+	}
+	for _, col := range q.CustCols {
+		if col["label"] == q.Request.sort {
+			return col
+		}
+	}
+	return nil
+}
+
+// CompositeExpr is the sql evaluating a composite column's template for each
+// book.
+func (q *query) CompositeExpr(col map[string]string) string {
+	return q.cache.stmt("composite/"+col["label"], func() string {
+		var buf bytes.Buffer
+		err := q.bookTmpl.ExecuteTemplate(&buf, "CompositeFields", q)
+		if err != nil {
+			log.Println("executing template:", err)
+		}
+		return fmt.Sprintf("composite(%s, %s)", sqlQuote(col["template"]), buf.String())
+	})
+}
+
+// custColSort orders books by the value of a custom column, so numbers, dates
+// and booleans sort by their sqlite type instead of as text. Series are
+// sorted by name, then position.
+func (q *query) custColSort(col map[string]string) string {
+	table := col["table"]
+	switch {
+	case col["datatype"] == "composite":
+		switch col["composite_sort"] {
+		case "number":
+			return "CAST(" + q.CompositeExpr(col) + " AS REAL)"
+		case "date":
+			return "date(" + q.CompositeExpr(col) + ")"
+		default:
+			return q.CompositeExpr(col)
+		}
+	case col["join_table"] != "":
+		return fmt.Sprintf("(SELECT MIN(%[1]s.value) FROM %[2]s JOIN %[1]s ON %[1]s.id = %[2]s.value WHERE %[2]s.book = books.id)", table, col["join_table"])
+	case col["link_table"] != "":
+		link := col["link_table"]
+		order := fmt.Sprintf("(SELECT %[1]s.value FROM %[2]s JOIN %[1]s ON %[1]s.id = %[2]s.value WHERE %[2]s.book = books.id)", table, link)
+		if col["datatype"] == "series" {
+			if q.Request.desc {
+				order += " DESC"
+			}
+			order += fmt.Sprintf(", (SELECT extra FROM %[1]s WHERE %[1]s.book = books.id)", link)
+		}
+		return order
+	default:
+		return fmt.Sprintf("(SELECT value FROM %s WHERE book = books.id)", table)
+	}
+}
+
+// seriesSort is the sort name of a book's series.
+const seriesSort = "(SELECT sort FROM series WHERE series.id IN (SELECT series FROM books_series_link WHERE book = books.id))"
+
 func BookSortField(f string) string {
 	var bookSortField = map[string]string{
+		"series":      seriesSort,
 		"authorSort":  "author_sort",
 		"sortAs":      "sort",
 		"added":       "timestamp",
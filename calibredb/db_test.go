@@ -0,0 +1,222 @@
+package calibredb
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// testLib is a library made from testdata/metadata.sql, 20 books with ids 1
+// to 20 and 16 more with international titles.
+func testLib(t *testing.T) *Lib {
+	t.Helper()
+	script, err := os.ReadFile(filepath.Join("testdata", "metadata.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "metadata.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(string(script)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return NewLib(dir)
+}
+
+type testResponse struct {
+	Data   []map[string]any    `json:"data"`
+	Errors []map[string]string `json:"errors"`
+	Meta   map[string]string   `json:"meta"`
+}
+
+func (r testResponse) titles() []string {
+	var titles []string
+	for _, b := range r.Data {
+		titles = append(titles, fmt.Sprint(b["title"]))
+	}
+	return titles
+}
+
+type getCase struct {
+	url   string
+	check func(testResponse) error
+}
+
+func bookCase(id int, title string) getCase {
+	return getCase{
+		url: fmt.Sprintf("/books/%d", id),
+		check: func(r testResponse) error {
+			if len(r.Data) != 1 || r.Data[0]["id"] != fmt.Sprint(id) || r.Data[0]["title"] != title {
+				return fmt.Errorf("got %v, want book %d %v", r.titles(), id, title)
+			}
+			return nil
+		},
+	}
+}
+
+func countCase(u string, n int) getCase {
+	return getCase{
+		url: u,
+		check: func(r testResponse) error {
+			if got := r.Meta["numberOfItems"]; got != fmt.Sprint(n) {
+				return fmt.Errorf("%v items, want %d", got, n)
+			}
+			return nil
+		},
+	}
+}
+
+func lenCase(u string, n int) getCase {
+	return getCase{
+		url: u,
+		check: func(r testResponse) error {
+			if len(r.Errors) != 0 || len(r.Data) != n {
+				return fmt.Errorf("%d items and errors %v, want %d items", len(r.Data), r.Errors, n)
+			}
+			return nil
+		},
+	}
+}
+
+func pageCase(page int, titles ...string) getCase {
+	return getCase{
+		url: fmt.Sprintf("/books?sort=title&itemsPerPage=%d&currentPage=%d", len(titles), page),
+		check: func(r testResponse) error {
+			if got := r.titles(); fmt.Sprint(got) != fmt.Sprint(titles) {
+				return fmt.Errorf("page %d is %v, want %v", page, got, titles)
+			}
+			return nil
+		},
+	}
+}
+
+func errCase(u, status string) getCase {
+	return getCase{
+		url: u,
+		check: func(r testResponse) error {
+			if len(r.Errors) != 1 || r.Errors[0]["status"] != status {
+				return fmt.Errorf("errors are %v, want %v", r.Errors, status)
+			}
+			return nil
+		},
+	}
+}
+
+// TestConcurrentGet runs requests with different params at the same time,
+// each response has to be the one for its own request.
+func TestConcurrentGet(t *testing.T) {
+	lib := testLib(t)
+
+	cases := []getCase{
+		countCase("/books", 36),
+		countCase("/books?vl=Audiobooks", 10),
+		countCase("/books?vl=Fantasy", 5),
+		countCase("/books?search=authors:zola", 20),
+		countCase("/books?search=%23pages:>400", 3),
+		countCase("/searches/fantasy%20audio", 5),
+		countCase("/authors", 5),
+		countCase("/tags?vl=Audiobooks", 2),
+		countCase("/formats", 2),
+		countCase("/identifiers", 20),
+		lenCase("/customColumns", 12),
+		pageCase(1, "'Quoted", "Apfel", "Ärger", "Book 001"),
+		pageCase(3, "Book 006", "Book 007", "Book 008", "Book 009"),
+		errCase("/books?vl=bogus", "400 Bad Request"),
+		errCase("/authors/99", "404 Not Found"),
+	}
+	for id := 1; id <= 20; id++ {
+		cases = append(cases, bookCase(id, fmt.Sprintf("Book %03d", id)))
+	}
+	cases = append(cases, bookCase(29, "東京物語"), bookCase(33, "Азбука"))
+
+	// the response of each request made on its own
+	want := make([][]byte, len(cases))
+	for i, c := range cases {
+		want[i] = lib.Get(c.url)
+		var resp testResponse
+		if err := json.Unmarshal(want[i], &resp); err != nil {
+			t.Fatalf("%v: %v", c.url, err)
+		}
+		if err := c.check(resp); err != nil {
+			t.Errorf("%v: %v", c.url, err)
+		}
+	}
+	if t.Failed() {
+		t.FailNow()
+	}
+
+	// without the response cache every request below runs its queries
+	lib.Cache().Disable()
+
+	var wg sync.WaitGroup
+	for n := 0; n < 400; n++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := cases[i]
+			got := lib.Get(c.url)
+
+			var resp testResponse
+			if err := json.Unmarshal(got, &resp); err != nil {
+				t.Errorf("%v: %v", c.url, err)
+				return
+			}
+			if err := c.check(resp); err != nil {
+				t.Errorf("%v: %v", c.url, err)
+			}
+			if !bytes.Equal(got, want[i]) {
+				t.Errorf("%v: concurrent response differs from a single request", c.url)
+			}
+		}(n % len(cases))
+	}
+	wg.Wait()
+}
+
+// TestCustomColumnSort sorts books by custom columns of their sqlite type,
+// as text a rating of 10 would come before 4.
+func TestCustomColumnSort(t *testing.T) {
+	lib := testLib(t)
+	for _, test := range []struct {
+		col  string
+		desc bool
+		want string
+	}{
+		{"myrating", false, "[4 4 10 10 10]"},
+		{"myrating", true, "[10 10 10 4 4]"},
+		{"read", false, "[false false false true true]"},
+		{"finished", false, "[2021-01-09 2021-05-09 2021-05-09 2021-09-09 2021-09-09]"},
+	} {
+		u := fmt.Sprintf("/books?sort=%%23%s&search=%%23%[1]s:true", test.col)
+		if test.desc {
+			u += "&order=desc"
+		}
+		var resp struct {
+			Data []struct {
+				CustomColumns map[string]struct {
+					Data any `json:"data"`
+				} `json:"customColumns"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(lib.Get(u), &resp); err != nil {
+			t.Fatalf("%v: %v", u, err)
+		}
+		var got []any
+		for _, b := range resp.Data {
+			got = append(got, b.CustomColumns["#"+test.col].Data)
+		}
+		if fmt.Sprint(got) != test.want {
+			t.Errorf("%v: sorted %v, want %v", u, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package calibredb
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// displayField is an entry of calibre's book_display_fields, the fields shown
+// in the book details and their order.
+type displayField struct {
+	Name string
+	Show bool
+}
+
+func parseDisplayFields(d json.RawMessage) []displayField {
+	var fields []displayField
+	if len(d) == 0 {
+		return fields
+	}
+	var pairs [][]any
+	if err := json.Unmarshal(d, &pairs); err != nil {
+		log.Printf("can't read book display fields: %v\n", err)
+		return fields
+	}
+	for _, p := range pairs {
+		if len(p) != 2 {
+			continue
+		}
+		name, _ := p[0].(string)
+		show, _ := p[1].(bool)
+		fields = append(fields, displayField{Name: GetJsonField(name), Show: show})
+	}
+	return fields
+}
+
+func parseHiddenCats(d json.RawMessage) []string {
+	var hidden []string
+	if len(d) == 0 {
+		return hidden
+	}
+	if err := json.Unmarshal(d, &hidden); err != nil {
+		log.Printf("can't read hidden categories: %v\n", err)
+	}
+	return hidden
+}
+
+// HiddenCategories are the categories hidden in calibre's tag browser.
+func (lib *Lib) HiddenCategories() []string {
+	return lib.hiddenCats
+}
+
+// VisibleCategories are the categories that aren't hidden.
+func (lib *Lib) VisibleCategories() []string {
+	var cats []string
+	for _, c := range lib.Categories() {
+		if !lib.isHidden(c) {
+			cats = append(cats, c)
+		}
+	}
+	return cats
+}
+
+func (lib *Lib) isHidden(cat string) bool {
+	return slices.Contains(lib.hiddenCats, cat) || slices.Contains(lib.hiddenCats, "#"+cat)
+}
+
+// categoryList lists the library's categories, hidden ones are only listed
+// with showHidden.
+func (q *query) categoryList() []map[string]string {
+	cats := q.VisibleCategories()
+	if q.Request.showHidden {
+		cats = q.Categories()
+	}
+
+	data := []map[string]string{}
+	for _, c := range cats {
+		item := map[string]string{
+			"id":    c,
+			"value": c,
+			"uri":   strings.TrimPrefix(c, "#"),
+		}
+		if name, ok := q.fieldMeta[c]["name"].(string); ok {
+			item["value"] = name
+		}
+		if q.isHidden(c) {
+			item["hidden"] = "true"
+		}
+		data = append(data, item)
+	}
+	return data
+}
+
+// displayBook is a book with its fields in calibre's display order, the
+// fields calibre doesn't display follow in alphabetical order.
+type displayBook struct {
+	keys   []string
+	fields map[string]json.RawMessage
+}
+
+// displayBooks drops the fields hidden by book_display_fields, custom columns
+// included, and orders the rest. It's only used for a book requested with
+// display=true, other clients need every field.
+func (q *query) displayBooks(data any) any {
+	books, ok := data.([]map[string]field)
+	if !ok || len(q.displayFields) == 0 {
+		return data
+	}
+
+	var display []displayBook
+	for _, b := range books {
+		fields := make(map[string]json.RawMessage)
+		for k, v := range b {
+			fields[k] = json.RawMessage(v)
+		}
+
+		if cols, ok := fields["customColumns"]; ok {
+			var custom map[string]json.RawMessage
+			if err := json.Unmarshal(cols, &custom); err == nil {
+				d := q.orderFields(custom)
+				if c, err := json.Marshal(d); err == nil {
+					fields["customColumns"] = c
+				}
+			}
+		}
+
+		display = append(display, q.orderFields(fields))
+	}
+	return display
+}
+
+func (q *query) orderFields(fields map[string]json.RawMessage) displayBook {
+	d := displayBook{fields: fields}
+	for _, f := range q.displayFields {
+		if _, ok := fields[f.Name]; !ok {
+			continue
+		}
+		if !f.Show {
+			delete(fields, f.Name)
+			continue
+		}
+		d.keys = append(d.keys, f.Name)
+	}
+
+	var rest []string
+	for k := range fields {
+		if !slices.Contains(d.keys, k) {
+			rest = append(rest, k)
+		}
+	}
+	slices.Sort(rest)
+	d.keys = append(d.keys, rest...)
+	return d
+}
+
+func (d displayBook) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, k := range d.keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(d.fields[k])
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,61 @@
+package calibredb
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestDisplayFields(t *testing.T) {
+	lib := testLib(t)
+
+	custom := func(u string) map[string]json.RawMessage {
+		var resp struct {
+			Data []struct {
+				CustomColumns map[string]json.RawMessage `json:"customColumns"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(lib.Get(u), &resp); err != nil || len(resp.Data) != 1 {
+			t.Fatalf("%v: %v", u, err)
+		}
+		return resp.Data[0].CustomColumns
+	}
+
+	// every field by default
+	if _, ok := custom("/books/1")["#duration"]; !ok {
+		t.Error("/books/1 is missing #duration")
+	}
+
+	// book_display_fields hides #duration and puts title and authors first
+	if _, ok := custom("/books/1?display=true")["#duration"]; ok {
+		t.Error("display=true kept #duration")
+	}
+	if d := lib.Get("/books/1?display=true"); !bytes.Contains(d, []byte(`"data":[{"title":"Book 001","authors":`)) {
+		t.Errorf("display=true isn't in display order: %.80s", d)
+	}
+}
+
+// TestCategoryURIs requests every category /categories lists, custom ones
+// included.
+func TestCategoryURIs(t *testing.T) {
+	lib := testLib(t)
+
+	var cats testResponse
+	if err := json.Unmarshal(lib.Get("/categories?showHidden=true"), &cats); err != nil {
+		t.Fatal(err)
+	}
+	if len(cats.Data) == 0 {
+		t.Fatal("no categories")
+	}
+	for _, c := range cats.Data {
+		u := "/" + c["uri"].(string)
+		var resp testResponse
+		if err := json.Unmarshal(lib.Get(u), &resp); err != nil {
+			t.Errorf("%v: %v", u, err)
+			continue
+		}
+		if len(resp.Errors) != 0 || len(resp.Data) == 0 {
+			t.Errorf("%v: %d items and errors %v", u, len(resp.Data), resp.Errors)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package calibredb
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log"
 	"strings"
@@ -43,7 +44,18 @@ END join_table,
 CASE is_multiple
 WHEN true THEN 'value'
 ELSE ""
-END link_column
+END link_column,
+datatype datatype,
+CASE IFNULL(normalized, 0)
+WHEN 0 THEN "false"
+ELSE "true"
+END normalized,
+CASE IFNULL(normalized, 0)
+WHEN 0 THEN ""
+ELSE "books_custom_column_" || id || "_link"
+END link_table,
+IFNULL(JSON_EXTRACT(display, "$.composite_template"), "") template,
+IFNULL(JSON_EXTRACT(display, "$.composite_sort"), "text") composite_sort
 FROM custom_columns;
 `
 
@@ -86,7 +98,13 @@ FROM preferences
 WHERE key = "field_metadata"
 `
 
-func GetFieldMeta(lib *Lib, f, v string) string {
+type fieldMeta interface {
+	getFieldMeta(f, v string) string
+}
+
+// GetFieldMeta is the sql template func for the calibre field metadata of
+// the Lib, or the query it's rendered with.
+func GetFieldMeta(lib fieldMeta, f, v string) string {
 	return lib.getFieldMeta(f, v)
 }
 
@@ -134,23 +152,31 @@ type Preferences struct {
 	SavedSearches    map[string]string `json:"savedSearches"`
 }
 
-func (lib *Lib) GetPref(p string) json.RawMessage {
+// GetPref returns the field_meta preference for fields.
+func (lib *Lib) GetPref(p string, fields ...string) json.RawMessage {
+	pref, _ := lib.getPref(context.Background(), p, fields)
+	return pref
+}
+
+func (lib *Lib) getPref(ctx context.Context, p string, fields []string) (json.RawMessage, error) {
 	var stmt string
 	switch p {
 	case "field_meta":
-		stmt = lib.renderFieldMetaTmpl()
+		stmt = lib.renderFieldMetaTmpl(fields)
 	}
 
-	row := lib.db.QueryRowx(stmt)
+	row := lib.db.QueryRowxContext(ctx, stmt)
 	var dbPref []byte
-	row.Scan(&dbPref)
+	if err := row.Scan(&dbPref); err != nil {
+		return nil, err
+	}
 
-	return json.RawMessage(dbPref)
+	return json.RawMessage(dbPref), ctx.Err()
 }
 
-func (lib *Lib) renderFieldMetaTmpl() string {
+func (lib *Lib) renderFieldMetaTmpl(fields []string) string {
 	var buf bytes.Buffer
-	err := lib.bookTmpl.ExecuteTemplate(&buf, "rangeFieldMeta", lib.Request.Fields)
+	err := lib.bookTmpl.ExecuteTemplate(&buf, "rangeFieldMeta", fields)
 	if err != nil {
 		log.Println("executing template:", err)
 	}
@@ -159,12 +185,24 @@ func (lib *Lib) renderFieldMetaTmpl() string {
 }
 
 func (lib *Lib) GetPreferences() json.RawMessage {
-	stmt := lib.renderSqlTmpl("Prefs")
-	row := lib.db.QueryRowx(stmt)
+	pref, _ := lib.preferences(context.Background())
+	return pref
+}
+
+func (lib *Lib) preferences(ctx context.Context) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	err := lib.bookTmpl.ExecuteTemplate(&buf, "Prefs", lib)
+	if err != nil {
+		log.Println("executing template:", err)
+	}
+	stmt := buf.String()
+	row := lib.db.QueryRowxContext(ctx, stmt)
 	var dbPref []byte
-	row.Scan(&dbPref)
+	if err := row.Scan(&dbPref); err != nil {
+		return nil, err
+	}
 
-	return json.RawMessage(dbPref)
+	return json.RawMessage(dbPref), ctx.Err()
 }
 
 type calibrePref struct {
@@ -177,6 +215,7 @@ type dbPreferences struct {
 	HiddenCategories json.RawMessage `json:"tag_browser_hidden_categories"`
 	DisplayFields    json.RawMessage `json:"book_display_fields"`
 	SavedSearches    json.RawMessage `json:"saved_searches"`
+	VirtualLibraries json.RawMessage `json:"virtual_libraries"`
 	FieldMeta        json.RawMessage `json:"field_metadata"`
 }
 
@@ -191,14 +230,17 @@ IN (
 	'saved_searches', 
 	'field_metadata', 
 	'book_display_fields', 
-	'tag_browser_hidden_categories'
+	'tag_browser_hidden_categories',
+	'virtual_libraries'
 )
 `
 
 func (lib *Lib) getPreferences() {
 	row := lib.db.QueryRowx(prefSql)
 	var dbPref []byte
-	row.Scan(&dbPref)
+	if err := row.Scan(&dbPref); err != nil {
+		log.Fatal(err)
+	}
 
 	var pref calibrePref
 	err := json.Unmarshal(dbPref, &pref)
@@ -211,6 +253,11 @@ func (lib *Lib) getPreferences() {
 		raw: pref,
 	}
 
+	lib.hiddenCats = parseHiddenCats(pref.HiddenCategories)
+	lib.displayFields = parseDisplayFields(pref.DisplayFields)
+	lib.savedSearches = parseSearchDefs(pref.SavedSearches)
+	lib.vlDefs = parseSearchDefs(pref.VirtualLibraries)
+
 	err = json.Unmarshal(pref.FieldMeta, &lib.fieldMeta)
 	if err != nil {
 		log.Fatalf("getDBfieldMeta json unmarshal failed: %v\n", err)
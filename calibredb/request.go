@@ -7,7 +7,6 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 
 	"golang.org/x/exp/slices"
 )
@@ -26,6 +25,13 @@ type request struct {
 	sort         string
 	pathID       string
 	PathID       string
+	VL           string
+	collation    string
+	search       string
+	searchCond   string
+	showHidden   bool
+	display      bool
+	detail       bool
 	queryIDs     string
 	Fields       []string
 	itemsPerPage int
@@ -37,14 +43,12 @@ type request struct {
 	desc         bool
 	collection   bool
 	bookQuery    bool
-	mtx          sync.Mutex
 }
 
-func (lib *Lib) newRequest(u string) (*request, error) {
+// newRequest parses the url into the query's request.
+func (q *query) newRequest(u string) error {
 	var req request
-
-	req.mtx.Lock()
-	defer req.mtx.Unlock()
+	q.Request = &req
 
 	uri, err := url.Parse(u)
 	if err != nil {
@@ -68,20 +72,44 @@ func (lib *Lib) newRequest(u string) (*request, error) {
 	req.URL = uri
 	req.query = req.URL.Query()
 	req.library = req.query.Get("library")
+	req.showHidden = req.query.Get("showHidden") == "true"
+	req.display = req.query.Get("display") == "true"
+
+	if vl := req.query.Get("vl"); vl != "" {
+		if _, ok := q.virtualLibs[vl]; !ok {
+			return fmt.Errorf("400 Bad Request:'%v' is not a virtual library", vl)
+		}
+		req.VL = vl
+	}
 
-	routeRegex := regexp.MustCompile("^/?([a-zA-Z]+)/?([0-9]+)?/?$")
-	matches := routeRegex.FindStringSubmatch(req.path)
-	if len(matches) == 0 {
-		return &req, fmt.Errorf("400 Bad Request:'%v' is not a valid URL", u)
+	if locale := req.query.Get("locale"); locale != "" {
+		c, err := localeCollation(locale)
+		if err != nil {
+			return fmt.Errorf("400 Bad Request:%v", err)
+		}
+		req.collation = c
+	}
+
+	// saved searches are requested by name, not id
+	var matches []string
+	if cat, name, _ := strings.Cut(strings.Trim(req.path, "/"), "/"); cat == "searches" {
+		matches = []string{req.path, cat, ""}
+		req.search = name
+	} else {
+		routeRegex := regexp.MustCompile("^/?([a-zA-Z]+)/?([0-9]+)?/?$")
+		matches = routeRegex.FindStringSubmatch(req.path)
+		if len(matches) == 0 {
+			return fmt.Errorf("400 Bad Request:'%v' is not a valid URL", u)
+		}
 	}
 	req.pathParams = matches[1:]
 
 	if matches[1] != "" {
 		req.cat = matches[1]
-		if !lib.validEndpoint(req.cat) {
-			return &req, fmt.Errorf("400 Bad Request:'%v' is not a valid enpoint", req.cat)
+		if !q.validEndpoint(req.cat) {
+			return fmt.Errorf("400 Bad Request:'%v' is not a valid enpoint", req.cat)
 		}
-		if req.cat == "narrators" {
+		if GetFieldMeta(q, req.cat, "custom_column") == "true" {
 			req.isCustom = true
 		}
 	}
@@ -95,7 +123,7 @@ func (lib *Lib) newRequest(u string) (*request, error) {
 		req.ids = matches[2]
 		req.pathID = matches[2]
 		req.PathID = matches[2]
-		if req.cat == "narrators" {
+		if GetFieldMeta(q, req.cat, "custom_column") == "true" {
 			req.isCustom = true
 			req.bookQuery = true
 		}
@@ -108,21 +136,47 @@ func (lib *Lib) newRequest(u string) (*request, error) {
 	req.CatLabel = req.cat
 
 	switch req.cat {
-	case "customColumns":
+	case "customColumns", "categories":
 		req.collection = false
 	case "preferences":
 		req.collection = false
 	case "books":
 		req.collection = true
 		req.bookQuery = true
+		req.detail = req.pathID != ""
+	case "searches":
+		if req.search != "" {
+			req.searchCond, err = q.savedSearch(req.search)
+			if err != nil {
+				return err
+			}
+			q.response.booksInCat = req.search
+			req.bookQuery = true
+		}
+		req.collection = true
 	default:
 		if req.pathID != "" {
-			req.ids = lib.booksInCatStmt(req.cat, req.pathID)
+			req.ids, err = q.booksInCatStmt(req.cat, req.pathID)
+			if err != nil {
+				return queryErr(err)
+			}
 			req.bookQuery = true
 		}
 		req.collection = true
 	}
 
+	// a search expression narrows the books further
+	if expr := req.query.Get("search"); expr != "" && req.bookQuery {
+		cond, err := q.compileSearch(expr)
+		if err != nil {
+			return fmt.Errorf("400 Bad Request:search: %v", err)
+		}
+		if req.searchCond != "" {
+			cond = "(" + req.searchCond + ") AND (" + cond + ")"
+		}
+		req.searchCond = cond
+	}
+
 	if req.query.Has("sort") {
 		req.isSorted = true
 		req.sort = req.query.Get("sort")
@@ -137,12 +191,14 @@ func (lib *Lib) newRequest(u string) (*request, error) {
 	if req.query.Has("fields") {
 		req.HasFields = true
 		req.Fields = strings.Split(req.query.Get("fields"), ",")
-		fmt.Println(req.Fields)
 
 		if req.cat != "books" {
 			if len(req.Fields) == 1 {
 				if slices.Contains(req.Fields, "books") {
-					req.ids = lib.booksInCatStmt(req.cat, req.ids)
+					req.ids, err = q.booksInCatStmt(req.cat, req.ids)
+					if err != nil {
+						return queryErr(err)
+					}
 					req.collection = true
 					req.bookQuery = true
 				}
@@ -151,7 +207,7 @@ func (lib *Lib) newRequest(u string) (*request, error) {
 	}
 
 	switch ids := req.ids; {
-	case ids != "":
+	case ids != "" && !req.allItems:
 		for _, id := range strings.Split(req.ids, ",") {
 			newID, err := strconv.Atoi(id)
 			if err != nil {
@@ -182,7 +238,7 @@ func (lib *Lib) newRequest(u string) (*request, error) {
 
 	//fmt.Printf("request params: %+v\n", req)
 
-	return &req, nil
+	return nil
 }
 
 func (r *request) calculateOffset() int {
@@ -27,43 +27,46 @@ func newResponse() *response {
 	return &resp
 }
 
-func (lib *Lib) setResponseURL() {
-	if lib.response.numberOfItems > 1 {
-		if lib.Request.cat != "preferences" {
-			if !lib.Request.query.Has("currentPage") {
-				lib.Request.query.Set("currentPage", "1")
+func (q *query) setResponseURL() {
+	if q.response.numberOfItems > 1 {
+		if q.Request.cat != "preferences" {
+			if !q.Request.query.Has("currentPage") {
+				q.Request.query.Set("currentPage", "1")
 			}
 		}
 	}
 	url := url.URL{
-		Path:     path.Join(lib.Request.path),
-		RawQuery: lib.Request.query.Encode(),
+		Path:     path.Join(q.Request.path),
+		RawQuery: q.Request.query.Encode(),
 	}
-	lib.response.addLink("self", url.String())
+	q.response.addLink("self", url.String())
 }
 
-func (lib *Lib) setResponseMeta() {
-	lib.response.addMeta("library", lib.Name)
+func (q *query) setResponseMeta() {
+	q.response.addMeta("library", q.Name)
 
-	if lib.Request.cat != "preferences" {
-		lib.response.addMeta("numberOfItems", strconv.Itoa(lib.response.numberOfItems))
-		lib.response.addMeta("currentPage", lib.Request.query.Get("currentPage"))
-		lib.response.addMeta("itemsPerPage", lib.Request.query.Get("itemsPerPage"))
+	if q.Request.cat != "preferences" {
+		q.response.addMeta("numberOfItems", strconv.Itoa(q.response.numberOfItems))
+		q.response.addMeta("currentPage", q.Request.query.Get("currentPage"))
+		q.response.addMeta("itemsPerPage", q.Request.query.Get("itemsPerPage"))
 	}
-	lib.response.addMeta("endpoint", lib.Request.cat)
-	lib.response.addMeta("categoryLabel", lib.Request.cat)
+	q.response.addMeta("endpoint", q.Request.cat)
+	if q.Request.VL != "" {
+		q.response.addMeta("virtualLibrary", q.Request.VL)
+	}
+	q.response.addMeta("categoryLabel", q.Request.cat)
 
-	if lib.response.booksInCat != "" {
-		lib.response.addMeta("categoryLabel", lib.response.booksInCat)
+	if q.response.booksInCat != "" {
+		q.response.addMeta("categoryLabel", q.response.booksInCat)
 	}
 }
 
-func (lib *Lib) setResponseData(data any) *Lib {
-	lib.response.Data = data
-	return lib
+func (q *query) setResponseData(data any) *query {
+	q.response.Data = data
+	return q
 }
 
-func (lib *Lib) calculatePagination() {
+func (q *query) calculatePagination() {
 	var (
 		prev  int
 		next  int
@@ -71,43 +74,41 @@ func (lib *Lib) calculatePagination() {
 		first = 1
 	)
 
-	if lib.Request.currentPage <= first {
+	if q.Request.currentPage <= first {
 		prev = first
 	} else {
-		prev = lib.Request.currentPage - 1
+		prev = q.Request.currentPage - 1
 	}
 
-	last = lib.response.numberOfItems / lib.Request.itemsPerPage
-	if r := lib.response.numberOfItems % lib.Request.itemsPerPage; r == 0 {
-		last = last
-	} else {
+	last = q.response.numberOfItems / q.Request.itemsPerPage
+	if r := q.response.numberOfItems % q.Request.itemsPerPage; r != 0 {
 		last = last + 1
 	}
 
-	if lib.Request.currentPage >= last {
+	if q.Request.currentPage >= last {
 		next = last
 	} else {
-		next = lib.Request.currentPage + 1
+		next = q.Request.currentPage + 1
 	}
 
-	rPath := path.Join(lib.Request.path)
-	lib.Request.query.Set("currentPage", strconv.Itoa(first))
-	firstPage := url.URL{Path: rPath, RawQuery: lib.Request.query.Encode()}
-	lib.response.addLink("first", firstPage.String())
+	rPath := path.Join(q.Request.path)
+	q.Request.query.Set("currentPage", strconv.Itoa(first))
+	firstPage := url.URL{Path: rPath, RawQuery: q.Request.query.Encode()}
+	q.response.addLink("first", firstPage.String())
 
-	lib.Request.query.Set("currentPage", strconv.Itoa(next))
-	nextPage := url.URL{Path: rPath, RawQuery: lib.Request.query.Encode()}
-	lib.response.addLink("next", nextPage.String())
+	q.Request.query.Set("currentPage", strconv.Itoa(next))
+	nextPage := url.URL{Path: rPath, RawQuery: q.Request.query.Encode()}
+	q.response.addLink("next", nextPage.String())
 
-	lib.Request.query.Set("currentPage", strconv.Itoa(prev))
-	prevPage := url.URL{Path: rPath, RawQuery: lib.Request.query.Encode()}
-	lib.response.addLink("prev", prevPage.String())
+	q.Request.query.Set("currentPage", strconv.Itoa(prev))
+	prevPage := url.URL{Path: rPath, RawQuery: q.Request.query.Encode()}
+	q.response.addLink("prev", prevPage.String())
 
-	lib.Request.query.Set("currentPage", strconv.Itoa(last))
-	lastPage := url.URL{Path: rPath, RawQuery: lib.Request.query.Encode()}
-	lib.response.addLink("last", lastPage.String())
+	q.Request.query.Set("currentPage", strconv.Itoa(last))
+	lastPage := url.URL{Path: rPath, RawQuery: q.Request.query.Encode()}
+	q.response.addLink("last", lastPage.String())
 
-	lib.Request.query.Set("currentPage", strconv.Itoa(lib.Request.currentPage))
+	q.Request.query.Set("currentPage", strconv.Itoa(q.Request.currentPage))
 }
 
 type responseErr struct {
@@ -118,8 +119,8 @@ type responseErr struct {
 func (r *response) addErr(e error) {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
-	errMsg := strings.Split(e.Error(), ":")
-	respErr := responseErr{Status: errMsg[0], Detail: errMsg[1]}
+	status, detail, _ := strings.Cut(e.Error(), ":")
+	respErr := responseErr{Status: status, Detail: detail}
 	r.Errors = append(r.Errors, respErr)
 }
 
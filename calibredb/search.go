@@ -0,0 +1,669 @@
+package calibredb
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/exp/slices"
+)
+
+// searchAliases are the other names calibre accepts for search fields.
+var searchAliases = map[string]string{
+	"author":      "authors",
+	"tag":         "tags",
+	"format":      "formats",
+	"language":    "languages",
+	"identifier":  "identifiers",
+	"comment":     "comments",
+	"description": "comments",
+	"publishers":  "publisher",
+	"date":        "timestamp",
+	"added":       "timestamp",
+	"published":   "pubdate",
+	"modified":    "last_modified",
+	"authorsort":  "author_sort",
+	"title_sort":  "sort",
+	"position":    "series_index",
+}
+
+// anyFields are searched by words without a field.
+var anyFields = []string{"title", "authors", "tags", "series", "publisher", "comments"}
+
+// undefinedDate is the date calibre stores for books without one.
+const undefinedDate = "0101-01-02"
+
+type searchToken struct {
+	text   string
+	quoted bool
+	paren  rune
+}
+
+type searchParser struct {
+	lib  *Lib
+	toks []searchToken
+	pos  int
+	seen []string
+}
+
+// getVirtualLibs compiles the virtual libraries of the library's
+// preferences, those that don't compile are left out.
+func (lib *Lib) getVirtualLibs() {
+	lib.virtualLibs = make(map[string]string)
+	for name, expr := range lib.vlDefs {
+		cond, err := lib.compileSearch(expr)
+		if err != nil {
+			log.Printf("virtual library %v: %v\n", name, err)
+			continue
+		}
+		lib.virtualLibs[name] = "SELECT id FROM books WHERE " + cond
+	}
+}
+
+// VirtualLibraries are the names of the library's virtual libraries.
+func (lib *Lib) VirtualLibraries() []string {
+	var names []string
+	for name := range lib.virtualLibs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// savedSearch compiles the named saved search.
+func (lib *Lib) savedSearch(name string) (string, error) {
+	expr, ok := lib.savedSearches[name]
+	if !ok {
+		return "", fmt.Errorf("404 Not Found:'%v' is not a saved search", name)
+	}
+	cond, err := lib.compileSearchSeen(expr, []string{"saved search:" + name})
+	if err != nil {
+		return "", fmt.Errorf("400 Bad Request:saved search %v: %v", name, err)
+	}
+	return cond, nil
+}
+
+// savedSearchList is the library's saved searches with the number of books
+// each finds.
+func (q *query) savedSearchList() (any, error) {
+	var names []string
+	for name := range q.savedSearches {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	data := []map[string]string{}
+	for _, name := range names {
+		item := map[string]string{
+			"id":     name,
+			"value":  name,
+			"search": q.savedSearches[name],
+			"uri":    "searches/" + url.PathEscape(name),
+			"books":  "0",
+		}
+
+		cond, err := q.savedSearch(name)
+		if err != nil {
+			_, item["error"], _ = strings.Cut(err.Error(), ":")
+			data = append(data, item)
+			continue
+		}
+		stmt := "SELECT COUNT(*) FROM books WHERE (" + cond + ")"
+		if vl := q.VirtualLibrary(); vl != "" {
+			stmt += " AND books.id IN (" + vl + ")"
+		}
+		var total int
+		if err := q.db.QueryRowxContext(q.ctx, stmt).Scan(&total); err != nil {
+			return nil, err
+		}
+		item["books"] = strconv.Itoa(total)
+		data = append(data, item)
+	}
+	return data, nil
+}
+
+func parseSearchDefs(d json.RawMessage) map[string]string {
+	defs := make(map[string]string)
+	if len(d) == 0 {
+		return defs
+	}
+	if err := json.Unmarshal(d, &defs); err != nil {
+		log.Printf("can't read searches: %v\n", err)
+	}
+	return defs
+}
+
+// compileSearch compiles a calibre search expression to a sql condition on
+// the books table.
+func (lib *Lib) compileSearch(expr string) (string, error) {
+	return lib.compileSearchSeen(expr, nil)
+}
+
+func (lib *Lib) compileSearchSeen(expr string, seen []string) (string, error) {
+	toks, err := tokenizeSearch(expr)
+	if err != nil {
+		return "", err
+	}
+	if len(toks) == 0 {
+		return "1", nil
+	}
+
+	p := &searchParser{lib: lib, toks: toks, seen: seen}
+	cond, err := p.or()
+	if err != nil {
+		return "", err
+	}
+	if p.pos < len(p.toks) {
+		return "", fmt.Errorf("unexpected %v in %q", p.toks[p.pos], expr)
+	}
+	return cond, nil
+}
+
+func (t searchToken) String() string {
+	if t.paren != 0 {
+		return string(t.paren)
+	}
+	return t.text
+}
+
+func tokenizeSearch(expr string) ([]searchToken, error) {
+	var (
+		toks []searchToken
+		r    = []rune(expr)
+	)
+	for i := 0; i < len(r); {
+		switch c := r[i]; {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')':
+			toks = append(toks, searchToken{paren: c})
+			i++
+		default:
+			var word strings.Builder
+			quoted := c == '"'
+			for i < len(r) && !unicode.IsSpace(r[i]) && r[i] != '(' && r[i] != ')' {
+				if r[i] != '"' {
+					word.WriteRune(r[i])
+					i++
+					continue
+				}
+				// quoted text can have spaces and parens
+				i++
+				for i < len(r) && r[i] != '"' {
+					if r[i] == '\\' && i+1 < len(r) {
+						i++
+					}
+					word.WriteRune(r[i])
+					i++
+				}
+				if i >= len(r) {
+					return nil, fmt.Errorf("missing closing quote in %q", expr)
+				}
+				i++
+			}
+			toks = append(toks, searchToken{text: word.String(), quoted: quoted})
+		}
+	}
+	return toks, nil
+}
+
+func (p *searchParser) keyword(k string) bool {
+	if p.pos >= len(p.toks) {
+		return false
+	}
+	t := p.toks[p.pos]
+	if t.paren != 0 || t.quoted || !strings.EqualFold(t.text, k) {
+		return false
+	}
+	p.pos++
+	return true
+}
+
+func (p *searchParser) or() (string, error) {
+	left, err := p.and()
+	if err != nil {
+		return "", err
+	}
+	for p.keyword("or") {
+		right, err := p.and()
+		if err != nil {
+			return "", err
+		}
+		left = "(" + left + " OR " + right + ")"
+	}
+	return left, nil
+}
+
+// and joins terms with and, or nothing between them.
+func (p *searchParser) and() (string, error) {
+	left, err := p.not()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if !p.keyword("and") {
+			if p.pos >= len(p.toks) || p.toks[p.pos].paren == ')' {
+				return left, nil
+			}
+			if t := p.toks[p.pos]; !t.quoted && strings.EqualFold(t.text, "or") {
+				return left, nil
+			}
+		}
+		right, err := p.not()
+		if err != nil {
+			return "", err
+		}
+		left = "(" + left + " AND " + right + ")"
+	}
+}
+
+func (p *searchParser) not() (string, error) {
+	if p.keyword("not") {
+		cond, err := p.not()
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + cond, nil
+	}
+	return p.term()
+}
+
+func (p *searchParser) term() (string, error) {
+	if p.pos >= len(p.toks) {
+		return "", fmt.Errorf("search ends too soon")
+	}
+	t := p.toks[p.pos]
+	p.pos++
+
+	switch t.paren {
+	case '(':
+		cond, err := p.or()
+		if err != nil {
+			return "", err
+		}
+		if p.pos >= len(p.toks) || p.toks[p.pos].paren != ')' {
+			return "", fmt.Errorf("missing closing paren")
+		}
+		p.pos++
+		return "(" + cond + ")", nil
+	case ')':
+		return "", fmt.Errorf("unexpected )")
+	}
+
+	// calibre searches everything for unknown fields
+	field, value, ok := strings.Cut(t.text, ":")
+	if t.quoted || !ok || !p.known(strings.ToLower(field)) {
+		return p.anyField(t.text)
+	}
+	return p.field(strings.ToLower(field), value)
+}
+
+// bookFields are searched without field metadata.
+var bookFields = []string{
+	"search", "vl", "isbn", "identifiers", "formats", "comments",
+	"title", "sort", "author_sort", "uuid",
+	"pubdate", "timestamp", "last_modified", "series_index", "cover",
+}
+
+func (p *searchParser) known(field string) bool {
+	if a, ok := searchAliases[field]; ok {
+		field = a
+	}
+	if slices.Contains(bookFields, field) {
+		return true
+	}
+	meta := p.lib.fieldMeta[field]
+	table, _ := meta["table"].(string)
+	column, _ := meta["column"].(string)
+	return table != "" && column != ""
+}
+
+// anyField matches a word without a field against the common text fields.
+func (p *searchParser) anyField(value string) (string, error) {
+	var conds []string
+	for _, f := range anyFields {
+		if !p.known(f) {
+			continue
+		}
+		cond, err := p.field(f, value)
+		if err != nil {
+			return "", err
+		}
+		conds = append(conds, cond)
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", nil
+}
+
+func (p *searchParser) field(field, value string) (string, error) {
+	if a, ok := searchAliases[field]; ok {
+		field = a
+	}
+
+	switch field {
+	case "search":
+		return p.expand("saved search", value, p.lib.savedSearches)
+	case "vl":
+		return p.expand("virtual library", value, p.lib.vlDefs)
+	case "isbn":
+		return identifierCond("isbn:" + value)
+	case "identifiers":
+		return identifierCond(value)
+	case "formats":
+		return linkedCond("data", "format", value, textCond)
+	case "comments":
+		return linkedCond("comments", "text", value, textCond)
+	case "title", "sort", "author_sort", "uuid":
+		return bookCond("books."+field, value, textCond)
+	case "pubdate", "timestamp", "last_modified":
+		return bookCond("books."+field, value, dateCond)
+	case "series_index":
+		return bookCond("books.series_index", value, numberCond(1))
+	case "cover":
+		switch strings.ToLower(value) {
+		case "true", "yes":
+			return "books.has_cover = 1", nil
+		case "false", "no":
+			return "books.has_cover = 0", nil
+		}
+		return "", fmt.Errorf("cover can only be true or false")
+	}
+
+	meta := p.lib.fieldMeta[field]
+	table, _ := meta["table"].(string)
+	column, _ := meta["column"].(string)
+	if table == "" || column == "" {
+		return "", fmt.Errorf("%v can't be searched", field)
+	}
+
+	match := textCond
+	switch meta["datatype"] {
+	case "int", "float":
+		match = numberCond(1)
+	case "rating":
+		match = numberCond(2)
+	case "datetime":
+		match = dateCond
+	case "bool":
+		match = boolCond
+	}
+
+	link, _ := meta["link_column"].(string)
+	if link == "" {
+		return linkedCond(table, column, value, match)
+	}
+
+	linkTable := "books_" + table + "_link"
+	switch hasValue(value) {
+	case "true":
+		return "books.id IN (SELECT book FROM " + linkTable + ")", nil
+	case "false":
+		return "books.id NOT IN (SELECT book FROM " + linkTable + ")", nil
+	}
+	cond, err := match(column, value)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("books.id IN (SELECT book FROM %s WHERE %s IN (SELECT id FROM %s WHERE %s))", linkTable, link, table, cond), nil
+}
+
+// expand compiles a saved search or virtual library used in a search.
+func (p *searchParser) expand(kind, name string, defs map[string]string) (string, error) {
+	name = strings.TrimPrefix(name, "=")
+	expr, ok := defs[name]
+	if !ok {
+		return "", fmt.Errorf("%v is not a %v", name, kind)
+	}
+	key := kind + ":" + name
+	if slices.Contains(p.seen, key) {
+		return "", fmt.Errorf("%v %v refers to itself", kind, name)
+	}
+	seen := append(slices.Clone(p.seen), key)
+	cond, err := p.lib.compileSearchSeen(expr, seen)
+	if err != nil {
+		return "", fmt.Errorf("%v %v: %v", kind, name, err)
+	}
+	return "(" + cond + ")", nil
+}
+
+type matchFunc func(column, value string) (string, error)
+
+// hasValue is true or false for searches for books with or without a value.
+func hasValue(value string) string {
+	switch strings.ToLower(value) {
+	case "true":
+		return "true"
+	case "false":
+		return "false"
+	}
+	return ""
+}
+
+func bookCond(column, value string, match matchFunc) (string, error) {
+	switch hasValue(value) {
+	case "true":
+		if strings.HasSuffix(column, "date") {
+			return column + " >= " + sqlQuote(undefinedDate), nil
+		}
+		return "IFNULL(" + column + ", '') != ''", nil
+	case "false":
+		if strings.HasSuffix(column, "date") {
+			return "IFNULL(" + column + ", '') < " + sqlQuote(undefinedDate), nil
+		}
+		return "IFNULL(" + column + ", '') = ''", nil
+	}
+	return match(column, value)
+}
+
+// linkedCond matches a column of a table with a book column.
+func linkedCond(table, column, value string, match matchFunc) (string, error) {
+	switch hasValue(value) {
+	case "true":
+		return "books.id IN (SELECT book FROM " + table + ")", nil
+	case "false":
+		return "books.id NOT IN (SELECT book FROM " + table + ")", nil
+	}
+	cond, err := match(column, value)
+	if err != nil {
+		return "", err
+	}
+	return "books.id IN (SELECT book FROM " + table + " WHERE " + cond + ")", nil
+}
+
+// identifierCond matches type:value, either can be left out.
+func identifierCond(value string) (string, error) {
+	if v := hasValue(value); v != "" {
+		return linkedCond("identifiers", "val", value, textCond)
+	}
+
+	kind, val, ok := strings.Cut(value, ":")
+	if !ok {
+		cond, err := textCond("type", kind)
+		if err != nil {
+			return "", err
+		}
+		return "books.id IN (SELECT book FROM identifiers WHERE " + cond + ")", nil
+	}
+
+	conds := []string{}
+	if kind != "" {
+		conds = append(conds, "lower(type) = "+sqlQuote(strings.ToLower(kind)))
+	}
+	if val != "" {
+		cond, err := textCond("val", val)
+		if err != nil {
+			return "", err
+		}
+		conds = append(conds, cond)
+	}
+	if len(conds) == 0 {
+		return "books.id IN (SELECT book FROM identifiers)", nil
+	}
+	return "books.id IN (SELECT book FROM identifiers WHERE " + strings.Join(conds, " AND ") + ")", nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// textCond matches text, =value matches exactly and ~value is a regular
+// expression, otherwise the column contains the value. Case is ignored.
+func textCond(column, value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "="):
+		return "lower(" + column + ") = lower(" + sqlQuote(value[1:]) + ")", nil
+	case strings.HasPrefix(value, "~"):
+		if _, err := regexp.Compile(value[1:]); err != nil {
+			return "", fmt.Errorf("bad regular expression %v: %v", value[1:], err)
+		}
+		return column + " REGEXP " + sqlQuote("(?i)"+value[1:]), nil
+	}
+	return column + " LIKE " + sqlQuote("%"+likeEscaper.Replace(value)+"%") + ` ESCAPE '\'`, nil
+}
+
+// searchOp splits a comparison operator from the start of a value.
+func searchOp(value string) (string, string) {
+	for _, op := range []string{">=", "<=", "!=", ">", "<", "="} {
+		if strings.HasPrefix(value, op) {
+			return op, value[len(op):]
+		}
+	}
+	return "=", value
+}
+
+// numberCond compares numbers, the value is multiplied by scale, eg ratings
+// are searched in stars and stored out of 10.
+func numberCond(scale float64) matchFunc {
+	return func(column, value string) (string, error) {
+		op, value := searchOp(value)
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("%v is not a number", value)
+		}
+		return column + " " + op + " " + strconv.FormatFloat(n*scale, 'f', -1, 64), nil
+	}
+}
+
+// dateCond compares dates by the day, month or year given. Dates can also be
+// today, yesterday, thismonth or a number of days ago, eg 10daysago.
+func dateCond(column, value string) (string, error) {
+	op, value := searchOp(value)
+	start, end, err := datePeriod(value)
+	if err != nil {
+		return "", err
+	}
+	s, e := sqlQuote(start.Format("2006-01-02")), sqlQuote(end.Format("2006-01-02"))
+
+	switch op {
+	case ">":
+		return column + " >= " + e, nil
+	case ">=":
+		return column + " >= " + s, nil
+	case "<":
+		return column + " < " + s, nil
+	case "<=":
+		return column + " < " + e, nil
+	case "!=":
+		return "NOT (" + column + " >= " + s + " AND " + column + " < " + e + ")", nil
+	}
+	return "(" + column + " >= " + s + " AND " + column + " < " + e + ")", nil
+}
+
+func datePeriod(value string) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch v := strings.ToLower(value); {
+	case v == "today":
+		return today, today.AddDate(0, 0, 1), nil
+	case v == "yesterday":
+		return today.AddDate(0, 0, -1), today, nil
+	case v == "thismonth":
+		month := today.AddDate(0, 0, 1-today.Day())
+		return month, month.AddDate(0, 1, 0), nil
+	case strings.HasSuffix(v, "daysago"):
+		n, err := strconv.Atoi(strings.TrimSuffix(v, "daysago"))
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%v is not a date", value)
+		}
+		day := today.AddDate(0, 0, -n)
+		return day, day.AddDate(0, 0, 1), nil
+	}
+
+	for _, p := range []struct {
+		layout string
+		y, m   int
+	}{
+		{"2006-01-02", 0, 0},
+		{"2006-01", 0, 1},
+		{"2006", 1, 0},
+	} {
+		if t, err := time.Parse(p.layout, value); err == nil {
+			if p.y == 0 && p.m == 0 {
+				return t, t.AddDate(0, 0, 1), nil
+			}
+			return t, t.AddDate(p.y, p.m, 0), nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("%v is not a date", value)
+}
+
+func boolCond(column, value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "yes":
+		return column + " = 1", nil
+	case "no":
+		return column + " = 0", nil
+	}
+	return "", fmt.Errorf("%v is not yes or no", value)
+}
+
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// maxRegexps bounds the compiled expressions regexpMatch keeps, they come
+// from search params so only the most recently used are kept.
+const maxRegexps = 64
+
+var (
+	regexps   = make(map[string]*list.Element)
+	regexpLRU = list.New()
+	regexpMtx sync.Mutex
+)
+
+// regexpMatch is sqlite's REGEXP function, the most recently used compiled
+// expressions are kept.
+func regexpMatch(expr, s string) (bool, error) {
+	re, err := compileRegexp(expr)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s), nil
+}
+
+func compileRegexp(expr string) (*regexp.Regexp, error) {
+	regexpMtx.Lock()
+	defer regexpMtx.Unlock()
+	if el, ok := regexps[expr]; ok {
+		regexpLRU.MoveToFront(el)
+		return el.Value.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexps[expr] = regexpLRU.PushFront(re)
+	for regexpLRU.Len() > maxRegexps {
+		el := regexpLRU.Back()
+		regexpLRU.Remove(el)
+		delete(regexps, el.Value.(*regexp.Regexp).String())
+	}
+	return re, nil
+}
@@ -0,0 +1,130 @@
+package calibredb
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type searchCase struct {
+	expr string
+	ids  []int
+}
+
+// searchIDs compiles expr and runs it against the books of lib.
+func searchIDs(lib *Lib, expr string) ([]int, error) {
+	cond, err := lib.compileSearch(expr)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	err = lib.db.Select(&ids, "SELECT id FROM books WHERE "+cond+" ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", cond, err)
+	}
+	return ids, nil
+}
+
+func testSearches(t *testing.T, lib *Lib, cases []searchCase) {
+	t.Helper()
+	for _, c := range cases {
+		ids, err := searchIDs(lib, c.expr)
+		if err != nil {
+			t.Errorf("%v: %v", c.expr, err)
+			continue
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(c.ids) {
+			t.Errorf("%v: got %v, want %v", c.expr, ids, c.ids)
+		}
+	}
+}
+
+func testSearchErrs(t *testing.T, lib *Lib, cases map[string]string) {
+	t.Helper()
+	for expr, want := range cases {
+		_, err := lib.compileSearch(expr)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: got error %v, want %q", expr, err, want)
+		}
+	}
+}
+
+func TestCompileSearch(t *testing.T) {
+	lib := testLib(t)
+	testSearches(t, lib, []searchCase{
+		{"tags:fantasy", []int{4, 8, 12, 16, 20}},
+		{"tags:fantasy or tags:sf", []int{1, 4, 5, 8, 9, 12, 13, 16, 17, 20}},
+		{"tags:sf and authors:\"Al Bo\"", []int{1}},
+		{"tags:sf authors:\"Al Bo\"", []int{1}},
+		{"not formats:m4b and authors:\"Al Bo\"", []int{1, 11}},
+		{"(tags:sf or tags:fantasy) and not authors:\"Jane Doe\"", []int{1, 4, 8, 9, 12, 13, 16, 17}},
+		{"title:\"=Book 010\"", []int{10}},
+		{"title:\"~^book 00[1-3]$\"", []int{1, 2, 3}},
+		{"isbn:9780000003", []int{3}},
+		{"rating:4", []int{3, 6, 9, 12, 15, 18}},
+		{"#pages:>400", []int{12, 16, 20}},
+		{"#read:yes", []int{8, 16}},
+		{"#myrating:5", []int{4, 12, 20}},
+		{"#genre:mystery", []int{8, 16}},
+		{"pubdate:<2003", []int{1, 2, 20}},
+		{"cover:false and title:eagle", []int{22}},
+		{"vl:Audiobooks and tags:fantasy", []int{4, 8, 12, 16, 20}},
+		{"vl:\"Not Jane\"", []int{3, 6, 9, 12, 18}},
+	})
+	for expr, n := range map[string]int{"": 36, "authors:zola": 20, "not authors:zola": 16} {
+		if ids, _ := searchIDs(lib, expr); len(ids) != n {
+			t.Errorf("%v found %d books, want %d", expr, len(ids), n)
+		}
+	}
+
+	testSearchErrs(t, lib, map[string]string{
+		"title:\"~(\"":     "bad regular expression",
+		"(tags:sf":         "",
+		"tags:sf and":      "",
+		"cover:maybe":      "cover can only be true or false",
+		"#read:maybe":      "is not yes or no",
+		"pubdate:whenever": "is not a date",
+		"vl:nope":          "nope is not a virtual library",
+	})
+}
+
+func TestRegexpsBounded(t *testing.T) {
+	for i := 0; i < maxRegexps*2; i++ {
+		if _, err := regexpMatch(fmt.Sprintf("^%d$", i), "0"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := regexpLRU.Len(); n != maxRegexps || len(regexps) != maxRegexps {
+		t.Errorf("%d compiled regexps kept, want %d", n, maxRegexps)
+	}
+	if _, err := regexpMatch("(", ""); err == nil {
+		t.Error("bad regexp matched")
+	}
+}
+
+func TestSavedSearches(t *testing.T) {
+	lib := testLib(t)
+	testSearches(t, lib, []searchCase{
+		{"search:\"fantasy audio\"", []int{4, 8, 12, 16, 20}},
+		{"search:\"recent fantasy audio\"", []int{12, 16}},
+		{"search:\"fantasy audio\" and not authors:\"Jane Doe\"", []int{4, 8, 12, 16}},
+		{"vl:Fantasy and pubdate:<2010", []int{4, 8, 20}},
+	})
+
+	testSearchErrs(t, lib, map[string]string{
+		"search:loop":                 "saved search loop refers to itself",
+		"tags:sf or search:loop":      "saved search loop refers to itself",
+		"search:nope":                 "nope is not a saved search",
+		"search:\"=fantasy audio\" (": "",
+	})
+
+	// the endpoint's errors carry their status
+	for name, want := range map[string]string{
+		"loop": "400 Bad Request:saved search loop: ",
+		"nope": "404 Not Found:",
+	} {
+		if _, err := lib.savedSearch(name); err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("saved search %v: got error %v, want %q", name, err, want)
+		}
+	}
+}
@@ -19,6 +19,7 @@ IFNULL(JSON_QUOTE({{$col}}), "") {{$key}},
 {{- end}}
 IFNULL(JSON_QUOTE(lower(id)), '""') id
 FROM {{$table}}
+{{with $lib.VirtualLibrary}}WHERE book IN ({{.}}){{end}}
 {{if eq $table "data" -}}
 GROUP BY extension
 {{- else if eq $table "identifiers" -}}
@@ -42,7 +43,8 @@ IFNULL(JSON_QUOTE({{$col}}), "") {{$key}},
 (SELECT
 JSON_QUOTE(lower(COUNT(book)))
 FROM books_{{$table}}_link
-WHERE {{$link}}={{$table}}.id) books,
+WHERE {{$link}}={{$table}}.id
+{{- with $lib.VirtualLibrary}} AND book IN ({{.}}){{end}}) books,
 IFNULL(JSON_QUOTE(lower(id)), "") id
 FROM {{$table}}
 {{- end -}}
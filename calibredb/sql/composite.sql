@@ -0,0 +1,89 @@
+{{define "CompositeFields"}}
+JSON_OBJECT(
+'id', books.id,
+'title', books.title,
+'sort', books.sort,
+'author_sort', books.author_sort,
+'uuid', books.uuid,
+'series_index', books.series_index,
+'pubdate', strftime('%Y-%m-%d', books.pubdate),
+'timestamp', strftime('%Y-%m-%d', books.timestamp),
+'last_modified', strftime('%Y-%m-%d', books.last_modified),
+'authors', (
+	SELECT group_concat(name, ' & ')
+	FROM (SELECT authors.name FROM books_authors_link
+		JOIN authors ON authors.id = books_authors_link.author
+		WHERE books_authors_link.book = books.id
+		ORDER BY books_authors_link.id)
+),
+'tags', (
+	SELECT group_concat(name, ', ')
+	FROM tags
+	WHERE id IN (SELECT tag FROM books_tags_link WHERE book = books.id)
+),
+'series', (
+	SELECT name
+	FROM series
+	WHERE id IN (SELECT series FROM books_series_link WHERE book = books.id)
+),
+'publisher', (
+	SELECT name
+	FROM publishers
+	WHERE id IN (SELECT publisher FROM books_publishers_link WHERE book = books.id)
+),
+'rating', (
+	SELECT rating / 2.0
+	FROM ratings
+	WHERE id IN (SELECT rating FROM books_ratings_link WHERE book = books.id)
+),
+'languages', (
+	SELECT group_concat(lang_code, ', ')
+	FROM languages
+	WHERE id IN (SELECT lang_code FROM books_languages_link WHERE book = books.id)
+),
+'identifiers', (
+	SELECT group_concat(type || ':' || val, ',')
+	FROM identifiers
+	WHERE book = books.id
+),
+'formats', (
+	SELECT group_concat(format, ', ')
+	FROM data
+	WHERE book = books.id
+),
+'comments', (
+	SELECT text
+	FROM comments
+	WHERE book = books.id
+)
+{{- range $col := .CustCols}}
+{{- if ne $col.datatype "composite"}},
+"{{$col.label}}", (
+{{- if ne $col.join_table ""}}
+	SELECT group_concat({{$col.table}}.value, '{{if eq $col.is_names "true"}} & {{else}}, {{end}}')
+	FROM {{$col.join_table}}
+	JOIN {{$col.table}} ON {{$col.table}}.id = {{$col.join_table}}.value
+	WHERE {{$col.join_table}}.book = books.id
+{{- else if ne $col.link_table ""}}
+	SELECT {{$col.table}}.value
+	FROM {{$col.link_table}}
+	JOIN {{$col.table}} ON {{$col.table}}.id = {{$col.link_table}}.value
+	WHERE {{$col.link_table}}.book = books.id
+{{- else if eq $col.datatype "bool"}}
+	SELECT CASE value WHEN 0 THEN 'No' ELSE 'Yes' END
+	FROM {{$col.table}}
+	WHERE book = books.id
+{{- else if eq $col.datatype "datetime"}}
+	SELECT strftime('%Y-%m-%d', value)
+	FROM {{$col.table}}
+	WHERE book = books.id
+{{- else}}
+	SELECT value
+	FROM {{$col.table}}
+	WHERE book = books.id
+{{- end}}
+)
+{{- end}}
+{{- end}}
+)
+{{- end}}
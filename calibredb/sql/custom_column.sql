@@ -1,7 +1,7 @@
 {{define "CustCol"}}
 JSON_OBJECT(
-{{range $col := .CustCols}}
-{{/* ltrim("{{$col.label}}", '#'), JSON_OBJECT( */}}
+{{range $i, $col := .CustCols}}
+{{- if $i}},{{end}}
 "{{$col.label}}", JSON_OBJECT(
 'meta', IFNULL(
 (
@@ -15,7 +15,12 @@ JSON_OBJECT(
 		CASE is_multiple
 		WHEN true THEN "true"
 		ELSE "false"
-		END)
+		END,
+		'normalized', "{{$col.normalized}}",
+		'datatype', datatype,
+		'interpret_as', IFNULL(JSON_EXTRACT(display, "$.interpret_as"), ""),
+		'enum_values', JSON(IFNULL(JSON_EXTRACT(display, "$.enum_values"), '[]')),
+		'template', IFNULL(JSON_EXTRACT(display, "$.composite_template"), ""))
 	FROM custom_columns
 	WHERE custom_columns.id = {{$col.id}}
 ), "{}"),
@@ -25,24 +30,67 @@ JSON_OBJECT(
 'data', IFNULL(
 (
 	SELECT JSON_GROUP_ARRAY(JSON_OBJECT(
-		'value', value, 
-		'id', lower({{$col.table}}.id), 
+		'value', value,
+		'id', lower({{$col.table}}.id),
 		'uri', ltrim("{{$col.label}}/", '#') || {{$col.table}}.id))
 	FROM {{$col.table}}
-	WHERE {{$col.table}}.id 
+	WHERE {{$col.table}}.id
 	IN (SELECT value
 		FROM {{$col.join_table}}
 		WHERE book=books.id)
 ), '[]')
-),
+)
+
+{{- else if eq $col.datatype "rating"}}
+'data', (
+	SELECT {{$col.table}}.value
+	FROM {{$col.link_table}}
+	JOIN {{$col.table}} ON {{$col.table}}.id = {{$col.link_table}}.value
+	WHERE {{$col.link_table}}.book = books.id
+)
+)
+
+{{- else if ne $col.link_table ""}}
+'data', (
+	SELECT JSON_OBJECT(
+		'value', {{$col.table}}.value,
+		'id', lower({{$col.table}}.id),
+		'uri', ltrim("{{$col.label}}/", '#') || {{$col.table}}.id
+		{{- if eq $col.datatype "series"}},
+		'position', {{$col.link_table}}.extra
+		{{- end}})
+	FROM {{$col.link_table}}
+	JOIN {{$col.table}} ON {{$col.table}}.id = {{$col.link_table}}.value
+	WHERE {{$col.link_table}}.book = books.id
+)
+)
+
+{{- else if eq $col.datatype "composite"}}
+'data', {{$.CompositeExpr $col}}
+)
+
+{{- else if eq $col.datatype "bool"}}
+'data', (
+	SELECT CASE value WHEN 0 THEN JSON('false') ELSE JSON('true') END
+	FROM {{$col.table}}
+	WHERE book=books.id
+)
+)
+
+{{- else if eq $col.datatype "datetime"}}
+'data', (
+	SELECT strftime('%Y-%m-%d', value)
+	FROM {{$col.table}}
+	WHERE book=books.id
+)
+)
 
 {{- else}}
-'data', IFNULL(
-(
-	SELECT JSON_QUOTE(value)
+'data', (
+	SELECT value
 	FROM {{$col.table}}
 	WHERE book=books.id
-), '')
+)
 )
 
 {{- end -}}
@@ -17,6 +17,7 @@ IN (
 	'saved_searches', 
 	'field_metadata', 
 	'book_display_fields', 
-	'tag_browser_hidden_categories'
+	'tag_browser_hidden_categories',
+	'virtual_libraries'
 )
 {{end}}
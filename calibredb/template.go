@@ -0,0 +1,575 @@
+package calibredb
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// compositeFunc is sqlite's composite function, it evaluates a composite
+// column's calibre template with the book's fields as a json object.
+func compositeFunc(tmpl, fields string) string {
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(fields), &raw); err != nil {
+		return fmt.Sprintf("TEMPLATE ERROR: %v", err)
+	}
+
+	vals := make(map[string]string)
+	for k, v := range raw {
+		switch val := v.(type) {
+		case string:
+			vals[k] = val
+		case float64:
+			vals[k] = strconv.FormatFloat(val, 'f', -1, 64)
+		case bool:
+			vals[k] = "No"
+			if val {
+				vals[k] = "Yes"
+			}
+		}
+	}
+
+	out, err := EvalTemplate(tmpl, vals)
+	if err != nil {
+		return fmt.Sprintf("TEMPLATE ERROR: %v", err)
+	}
+	return out
+}
+
+// EvalTemplate evaluates a calibre template with a book's fields, keyed by
+// their calibre lookup name. It knows a subset of calibre's template
+// language: {field}, {field:|prefix|suffix} and program: mode with
+// assignments, if/then/elif/else/fi and the field, raw_field, ifempty, test,
+// lookup, sublist, re, list_join, format_date, strcat, uppercase and
+// lowercase functions.
+func EvalTemplate(tmpl string, fields map[string]string) (string, error) {
+	if strings.HasPrefix(tmpl, "program:") {
+		p := &tmplParser{
+			fields: fields,
+			vars:   make(map[string]string),
+		}
+		toks, err := lexTemplate(strings.TrimPrefix(tmpl, "program:"))
+		if err != nil {
+			return "", err
+		}
+		p.toks = toks
+		val, err := p.statements(true)
+		if err != nil {
+			return "", err
+		}
+		if p.pos < len(p.toks) {
+			return "", fmt.Errorf("unexpected %q", p.toks[p.pos].val)
+		}
+		return val, nil
+	}
+	return evalBasic(tmpl, fields)
+}
+
+func evalBasic(tmpl string, fields map[string]string) (string, error) {
+	var out strings.Builder
+	for {
+		start := strings.Index(tmpl, "{")
+		if start < 0 {
+			out.WriteString(tmpl)
+			return out.String(), nil
+		}
+		end := strings.Index(tmpl[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("missing } in %q", tmpl[start:])
+		}
+		out.WriteString(tmpl[:start])
+
+		name, spec, _ := strings.Cut(tmpl[start+1:start+end], ":")
+		val := fieldValue(fields, strings.TrimSpace(name))
+		if _, affix, ok := strings.Cut(spec, "|"); ok && val != "" {
+			prefix, suffix, _ := strings.Cut(affix, "|")
+			val = prefix + val + suffix
+		}
+		out.WriteString(val)
+
+		tmpl = tmpl[start+end+1:]
+	}
+}
+
+func fieldValue(fields map[string]string, name string) string {
+	if v, ok := fields[name]; ok {
+		return v
+	}
+	return fields[strings.ToLower(name)]
+}
+
+const (
+	tokString = iota
+	tokNumber
+	tokIdent
+	tokField
+	tokPunct
+)
+
+type tmplToken struct {
+	kind int
+	val  string
+}
+
+func lexTemplate(src string) ([]tmplToken, error) {
+	var toks []tmplToken
+	r := []rune(src)
+	for i := 0; i < len(r); {
+		c := r[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'' || c == '"':
+			var s strings.Builder
+			j := i + 1
+			for ; j < len(r) && r[j] != c; j++ {
+				// only quotes are escaped, regexps keep their backslashes
+				if r[j] == '\\' && j+1 < len(r) && r[j+1] == c {
+					j++
+				}
+				s.WriteRune(r[j])
+			}
+			if j == len(r) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			toks = append(toks, tmplToken{tokString, s.String()})
+			i = j + 1
+		case c == '$':
+			j := i + 1
+			for j < len(r) && r[j] == '$' {
+				j++
+			}
+			k := j
+			for k < len(r) && (isIdentRune(r[k]) || r[k] == '#' && k == j) {
+				k++
+			}
+			toks = append(toks, tmplToken{tokField, string(r[j:k])})
+			i = k
+		case unicode.IsDigit(c) || c == '-' && i+1 < len(r) && unicode.IsDigit(r[i+1]):
+			j := i + 1
+			for j < len(r) && (unicode.IsDigit(r[j]) || r[j] == '.') {
+				j++
+			}
+			toks = append(toks, tmplToken{tokNumber, string(r[i:j])})
+			i = j
+		case isIdentRune(c):
+			j := i
+			for j < len(r) && isIdentRune(r[j]) {
+				j++
+			}
+			toks = append(toks, tmplToken{tokIdent, string(r[i:j])})
+			i = j
+		case c == '#':
+			// comments run to the end of the line
+			for i < len(r) && r[i] != '\n' {
+				i++
+			}
+		case strings.ContainsRune("(),;=", c):
+			toks = append(toks, tmplToken{tokPunct, string(c)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+	}
+	return toks, nil
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+type tmplParser struct {
+	toks   []tmplToken
+	pos    int
+	fields map[string]string
+	vars   map[string]string
+}
+
+func (p *tmplParser) peek() tmplToken {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return tmplToken{kind: -1}
+}
+
+func (p *tmplParser) isKeyword(kws ...string) bool {
+	t := p.peek()
+	if t.kind != tokIdent {
+		return false
+	}
+	for _, kw := range kws {
+		if t.val == kw {
+			return true
+		}
+	}
+	return false
+}
+
+// isPunct is true when the next token is the punctuation c, not a string
+// holding it.
+func (p *tmplParser) isPunct(c string) bool {
+	t := p.peek()
+	return t.kind == tokPunct && t.val == c
+}
+
+func (p *tmplParser) expect(val string) error {
+	if t := p.peek(); t.val != val || t.kind == tokString {
+		return fmt.Errorf("expected %q, got %q", val, t.val)
+	}
+	p.pos++
+	return nil
+}
+
+// statements evaluates statements separated by semicolons up to the end of
+// the template or a keyword closing a block, their value is the last one's.
+// When eval is false they're only parsed.
+func (p *tmplParser) statements(eval bool) (string, error) {
+	var val string
+	for p.pos < len(p.toks) && !p.isKeyword("elif", "else", "fi") {
+		if p.isPunct(";") {
+			p.pos++
+			continue
+		}
+		v, err := p.statement(eval)
+		if err != nil {
+			return "", err
+		}
+		val = v
+	}
+	return val, nil
+}
+
+func (p *tmplParser) statement(eval bool) (string, error) {
+	if t := p.peek(); t.kind == tokIdent && p.pos+1 < len(p.toks) && p.toks[p.pos+1].val == "=" {
+		p.pos += 2
+		val, err := p.expr(eval)
+		if err != nil {
+			return "", err
+		}
+		if eval {
+			p.vars[t.val] = val
+		}
+		return val, nil
+	}
+	return p.expr(eval)
+}
+
+func (p *tmplParser) expr(eval bool) (string, error) {
+	t := p.peek()
+	if t.kind < 0 {
+		return "", fmt.Errorf("unexpected end of template")
+	}
+	p.pos++
+
+	switch t.kind {
+	case tokString, tokNumber:
+		return t.val, nil
+	case tokField:
+		if t.val == "" {
+			return "", nil
+		}
+		return fieldValue(p.fields, t.val), nil
+	case tokIdent:
+		switch {
+		case t.val == "if":
+			return p.ifExpr(eval)
+		case p.isPunct("("):
+			return p.call(t.val, eval)
+		default:
+			return p.vars[t.val], nil
+		}
+	}
+	return "", fmt.Errorf("unexpected %q", t.val)
+}
+
+func (p *tmplParser) ifExpr(eval bool) (string, error) {
+	var (
+		val  string
+		done = !eval
+	)
+	for {
+		cond, err := p.expr(!done)
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("then"); err != nil {
+			return "", err
+		}
+		take := !done && cond != ""
+		v, err := p.statements(take)
+		if err != nil {
+			return "", err
+		}
+		if take {
+			val, done = v, true
+		}
+
+		switch {
+		case p.isKeyword("elif"):
+			p.pos++
+			continue
+		case p.isKeyword("else"):
+			p.pos++
+			v, err := p.statements(!done)
+			if err != nil {
+				return "", err
+			}
+			if !done {
+				val = v
+			}
+		}
+		return val, p.expect("fi")
+	}
+}
+
+func (p *tmplParser) call(name string, eval bool) (string, error) {
+	if err := p.expect("("); err != nil {
+		return "", err
+	}
+	var args []string
+	for !p.isPunct(")") {
+		arg, err := p.expr(eval)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, arg)
+		if p.isPunct(",") {
+			p.pos++
+		} else if !p.isPunct(")") {
+			return "", fmt.Errorf("expected , or ) in %v()", name)
+		}
+	}
+	p.pos++
+
+	if !eval {
+		return "", nil
+	}
+	return p.callFunc(name, args)
+}
+
+func (p *tmplParser) callFunc(name string, args []string) (string, error) {
+	nargs := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("%v() takes %d arguments", name, n)
+		}
+		return nil
+	}
+
+	switch name {
+	case "field", "raw_field":
+		if err := nargs(1); err != nil {
+			return "", err
+		}
+		return fieldValue(p.fields, args[0]), nil
+	case "ifempty":
+		if err := nargs(2); err != nil {
+			return "", err
+		}
+		if args[0] == "" {
+			return args[1], nil
+		}
+		return args[0], nil
+	case "test":
+		if err := nargs(3); err != nil {
+			return "", err
+		}
+		if args[0] != "" {
+			return args[1], nil
+		}
+		return args[2], nil
+	case "lookup":
+		if len(args) < 2 || len(args)%2 != 0 {
+			return "", fmt.Errorf("lookup() takes a value, pattern and field pairs and a field")
+		}
+		for i := 1; i+1 < len(args); i += 2 {
+			re, err := regexp.Compile("(?i)" + args[i])
+			if err != nil {
+				return "", err
+			}
+			if re.MatchString(args[0]) {
+				return fieldValue(p.fields, args[i+1]), nil
+			}
+		}
+		return fieldValue(p.fields, args[len(args)-1]), nil
+	case "sublist":
+		if err := nargs(4); err != nil {
+			return "", err
+		}
+		return sublist(args[0], args[1], args[2], args[3])
+	case "re":
+		if err := nargs(3); err != nil {
+			return "", err
+		}
+		re, err := regexp.Compile(args[1])
+		if err != nil {
+			return "", err
+		}
+		repl := pyBackrefs.ReplaceAllString(strings.ReplaceAll(args[2], "$", "$$"), "$${$1}")
+		return re.ReplaceAllString(args[0], repl), nil
+	case "list_join":
+		if len(args) < 3 || len(args)%2 != 1 {
+			return "", fmt.Errorf("list_join() takes a separator and list and separator pairs")
+		}
+		var items []string
+		for i := 1; i+1 < len(args); i += 2 {
+			items = append(items, splitList(args[i], args[i+1])...)
+		}
+		return strings.Join(items, args[0]), nil
+	case "format_date":
+		if err := nargs(2); err != nil {
+			return "", err
+		}
+		return formatCalibreDate(args[0], args[1]), nil
+	case "strcat":
+		return strings.Join(args, ""), nil
+	case "uppercase":
+		if err := nargs(1); err != nil {
+			return "", err
+		}
+		return strings.ToUpper(args[0]), nil
+	case "lowercase":
+		if err := nargs(1); err != nil {
+			return "", err
+		}
+		return strings.ToLower(args[0]), nil
+	}
+	return "", fmt.Errorf("unknown function %v", name)
+}
+
+var pyBackrefs = regexp.MustCompile(`\\(\d+)`)
+
+func splitList(list, sep string) []string {
+	var items []string
+	if sep == "" {
+		sep = ","
+	}
+	for _, i := range strings.Split(list, sep) {
+		if i = strings.TrimSpace(i); i != "" {
+			items = append(items, i)
+		}
+	}
+	return items
+}
+
+// sublist slices a list like python, an end of 0 is the end of the list.
+func sublist(list, start, end, sep string) (string, error) {
+	s, err := strconv.Atoi(start)
+	if err != nil {
+		return "", fmt.Errorf("sublist() start %v is not a number", start)
+	}
+	e, err := strconv.Atoi(end)
+	if err != nil {
+		return "", fmt.Errorf("sublist() end %v is not a number", end)
+	}
+
+	items := splitList(list, sep)
+	n := len(items)
+	if e == 0 {
+		e = n
+	}
+	if s < 0 {
+		s += n
+	}
+	if e < 0 {
+		e += n
+	}
+	s, e = clamp(s, 0, n), clamp(e, 0, n)
+	if s >= e {
+		return "", nil
+	}
+
+	join := sep
+	if strings.TrimSpace(sep) == "," {
+		join = ", "
+	}
+	return strings.Join(items[s:e], join), nil
+}
+
+func clamp(i, lo, hi int) int {
+	switch {
+	case i < lo:
+		return lo
+	case i > hi:
+		return hi
+	}
+	return i
+}
+
+var calibreDateCodes = regexp.MustCompile(`iso|yyyy|yy|MMMM|MMM|MM|M|dddd|ddd|dd|d|hh|h|mm|m|ss|s|AP|ap`)
+
+// formatCalibreDate formats a date with calibre's format codes, an empty
+// date stays empty.
+func formatCalibreDate(val, format string) string {
+	t, ok := parseTemplateDate(val)
+	if !ok {
+		return ""
+	}
+
+	ampm := strings.Contains(format, "ap") || strings.Contains(format, "AP")
+	return calibreDateCodes.ReplaceAllStringFunc(format, func(code string) string {
+		switch code {
+		case "iso":
+			return t.Format(time.RFC3339)
+		case "yyyy":
+			return t.Format("2006")
+		case "yy":
+			return t.Format("06")
+		case "MMMM":
+			return t.Format("January")
+		case "MMM":
+			return t.Format("Jan")
+		case "MM":
+			return t.Format("01")
+		case "M":
+			return strconv.Itoa(int(t.Month()))
+		case "dddd":
+			return t.Format("Monday")
+		case "ddd":
+			return t.Format("Mon")
+		case "dd":
+			return t.Format("02")
+		case "d":
+			return strconv.Itoa(t.Day())
+		case "hh", "h":
+			h := t.Hour()
+			if ampm {
+				h = (h+11)%12 + 1
+			}
+			if code == "hh" {
+				return fmt.Sprintf("%02d", h)
+			}
+			return strconv.Itoa(h)
+		case "mm":
+			return t.Format("04")
+		case "m":
+			return strconv.Itoa(t.Minute())
+		case "ss":
+			return t.Format("05")
+		case "s":
+			return strconv.Itoa(t.Second())
+		case "ap":
+			return strings.ToLower(t.Format("PM"))
+		case "AP":
+			return t.Format("PM")
+		}
+		return code
+	})
+}
+
+func parseTemplateDate(val string) (time.Time, bool) {
+	for _, layout := range []string{
+		"2006-01-02",
+		time.RFC3339,
+		"2006-01-02 15:04:05-07:00",
+		"2006-01-02 15:04:05",
+	} {
+		if t, err := time.Parse(layout, strings.TrimSpace(val)); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,140 @@
+package calibredb
+
+import (
+	"strings"
+	"testing"
+)
+
+var tmplFields = map[string]string{
+	"title":     "The Hobbit",
+	"authors":   "J. R. R. Tolkien",
+	"series":    "Middle-earth",
+	"tags":      "fantasy, classics, dragons",
+	"pubdate":   "1937-09-21 00:00:00+00:00",
+	"#genre":    "Fantasy",
+	"#read":     "Yes",
+	"#pages":    "310",
+	"#subtitle": "",
+}
+
+type tmplCase struct {
+	tmpl string
+	want string
+}
+
+func testTemplates(t *testing.T, cases []tmplCase) {
+	t.Helper()
+	for _, c := range cases {
+		got, err := EvalTemplate(c.tmpl, tmplFields)
+		if err != nil {
+			t.Errorf("%v: %v", c.tmpl, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%v: got %q, want %q", c.tmpl, got, c.want)
+		}
+	}
+}
+
+func TestBasicTemplate(t *testing.T) {
+	testTemplates(t, []tmplCase{
+		{"{title} by {authors}", "The Hobbit by J. R. R. Tolkien"},
+		{"{TITLE}", "The Hobbit"},
+		{"{#genre}", "Fantasy"},
+		{"{missing}", ""},
+		{"no fields", "no fields"},
+		{"{series:|(|)}", "(Middle-earth)"},
+		{"{#subtitle:|: |}", ""},
+		{"{title}{#subtitle:|: |}", "The Hobbit"},
+		{"{#pages:| (| pages)}", " (310 pages)"},
+	})
+}
+
+func TestProgramFuncs(t *testing.T) {
+	testTemplates(t, []tmplCase{
+		{"program: field('title')", "The Hobbit"},
+		{"program: raw_field('#pages')", "310"},
+		{"program: $title", "The Hobbit"},
+		{"program: ifempty($#subtitle, 'none')", "none"},
+		{"program: ifempty($title, 'none')", "The Hobbit"},
+		{"program: test($#read, 'read', 'unread')", "read"},
+		{"program: test($#subtitle, 'yes', 'no')", "no"},
+		{"program: lookup($#genre, 'fant', 'series', 'title')", "Middle-earth"},
+		{"program: lookup($#genre, 'horror', 'series', 'title')", "The Hobbit"},
+		{"program: sublist($tags, 0, 1, ',')", "fantasy"},
+		{"program: sublist($tags, -1, 0, ',')", "dragons"},
+		{"program: sublist($tags, 1, 0, ',')", "classics, dragons"},
+		{"program: sublist($tags, 2, 1, ',')", ""},
+		{"program: re($title, '^The (.*)$', '\\1, The')", "Hobbit, The"},
+		{"program: re($#pages, '0', '$')", "31$"},
+		{"program: list_join(' / ', $tags, ',', $#genre, ',')", "fantasy / classics / dragons / Fantasy"},
+		{"program: format_date($pubdate, 'yyyy')", "1937"},
+		{"program: format_date($pubdate, 'd MMMM yy')", "21 September 37"},
+		{"program: format_date($pubdate, 'dd/MM hh:mm ap')", "21/09 12:00 am"},
+		{"program: format_date('', 'yyyy')", ""},
+		{"program: strcat($title, ' (', $#pages, ')')", "The Hobbit (310)"},
+		{"program: strcat()", ""},
+		{"program: strcat('(', ',', ';', ')')", "(,;)"},
+		{"program: uppercase($#genre)", "FANTASY"},
+		{"program: lowercase($title)", "the hobbit"},
+	})
+}
+
+func TestProgramMode(t *testing.T) {
+	testTemplates(t, []tmplCase{
+		{"program: a = 'x'; b = strcat(a, 'y'); b", "xy"},
+		{"program: if $#read then 'done' fi", "done"},
+		{"program: if $#subtitle then 'done' fi", ""},
+		{"program: if $#subtitle then 'a' else 'b' fi", "b"},
+		{"program: if $#subtitle then 'a' elif $#genre then 'b' else 'c' fi", "b"},
+		{"program: if $#subtitle then 'a' elif $#missing then 'b' else 'c' fi", "c"},
+		{"program: if $title then 'a' elif $#genre then 'b' else 'c' fi", "a"},
+		{"program:\n# the series or the title\nif $series then $series else $title fi", "Middle-earth"},
+		{"program: if $#read then if $#subtitle then 'x' else 'y' fi fi", "y"},
+		// only the branch taken is evaluated
+		{"program: if $title then 'ok' else uppercase('a', 'b') fi", "ok"},
+		{"program: x = if $#read then 'r' fi; strcat(x, x)", "rr"},
+		{"program: if 'then' then 'fi' else 'else' fi", "fi"},
+	})
+}
+
+func TestTemplateErrors(t *testing.T) {
+	for tmpl, want := range map[string]string{
+		"program: 'unterminated":               "unterminated string",
+		"program: strcat('a)":                  "unterminated string",
+		"program: uppercase()":                 "uppercase() takes 1 arguments",
+		"program: test('a', 'b')":              "test() takes 3 arguments",
+		"program: sublist($tags, 0, 1)":        "sublist() takes 4 arguments",
+		"program: sublist($tags, 'a', 1, ',')": "start a is not a number",
+		"program: lookup('a')":                 "lookup() takes",
+		"program: list_join(',', $tags)":       "list_join() takes",
+		"program: format_date($pubdate)":       "format_date() takes 2 arguments",
+		"program: nope('a')":                   "unknown function nope",
+		"program: re('a', '(', 'b')":           "missing closing )",
+		"program: if $title then 'a'":          "fi",
+		"program: if $title 'a' fi":            "then",
+		"program: strcat('a' 'b')":             "expected , or ) in strcat()",
+		"program: 'a' )":                       "unexpected",
+		"program: a = ":                        "unexpected end of template",
+		"program: @":                           "unexpected",
+		"{title":                               "missing }",
+	} {
+		_, err := EvalTemplate(tmpl, tmplFields)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: got error %v, want %q", tmpl, err, want)
+		}
+	}
+}
+
+func TestCompositeFunc(t *testing.T) {
+	got := compositeFunc("{title}: {#read} {#pages}", `{"title": "Dune", "#read": true, "#pages": 412}`)
+	if got != "Dune: Yes 412" {
+		t.Errorf("got %q", got)
+	}
+	if got := compositeFunc("program: nope()", `{}`); !strings.HasPrefix(got, "TEMPLATE ERROR: ") {
+		t.Errorf("a template error is %q", got)
+	}
+	if got := compositeFunc("{title}", `not json`); !strings.HasPrefix(got, "TEMPLATE ERROR: ") {
+		t.Errorf("bad fields are %q", got)
+	}
+}
@@ -0,0 +1,391 @@
+CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, sort TEXT, timestamp TIMESTAMP, pubdate TIMESTAMP, series_index REAL DEFAULT 1.0, author_sort TEXT, path TEXT, uuid TEXT, has_cover BOOL DEFAULT 0, last_modified TIMESTAMP);
+CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT, sort TEXT, link TEXT DEFAULT '');
+CREATE TABLE books_authors_link (id INTEGER PRIMARY KEY, book INTEGER, author INTEGER);
+CREATE TABLE tags (id INTEGER PRIMARY KEY, name TEXT);
+CREATE TABLE books_tags_link (id INTEGER PRIMARY KEY, book INTEGER, tag INTEGER);
+CREATE TABLE languages (id INTEGER PRIMARY KEY, lang_code TEXT);
+CREATE TABLE books_languages_link (id INTEGER PRIMARY KEY, book INTEGER, lang_code INTEGER, item_order INTEGER DEFAULT 0);
+CREATE TABLE publishers (id INTEGER PRIMARY KEY, name TEXT, sort TEXT);
+CREATE TABLE books_publishers_link (id INTEGER PRIMARY KEY, book INTEGER, publisher INTEGER);
+CREATE TABLE series (id INTEGER PRIMARY KEY, name TEXT, sort TEXT);
+CREATE TABLE books_series_link (id INTEGER PRIMARY KEY, book INTEGER, series INTEGER);
+CREATE TABLE ratings (id INTEGER PRIMARY KEY, rating INTEGER);
+CREATE TABLE books_ratings_link (id INTEGER PRIMARY KEY, book INTEGER, rating INTEGER);
+CREATE TABLE data (id INTEGER PRIMARY KEY, book INTEGER, format TEXT, uncompressed_size INTEGER, name TEXT);
+CREATE TABLE identifiers (id INTEGER PRIMARY KEY, book INTEGER, type TEXT, val TEXT);
+CREATE TABLE comments (id INTEGER PRIMARY KEY, book INTEGER, text TEXT);
+CREATE TABLE custom_columns (id INTEGER PRIMARY KEY, label TEXT, name TEXT, datatype TEXT, mark_for_delete BOOL DEFAULT 0, editable BOOL DEFAULT 1, display TEXT DEFAULT '{}', is_multiple BOOL DEFAULT 0, normalized BOOL);
+CREATE TABLE custom_column_1 (id INTEGER PRIMARY KEY, value TEXT, link TEXT DEFAULT '');
+CREATE TABLE books_custom_column_1_link (id INTEGER PRIMARY KEY, book INTEGER, value INTEGER);
+CREATE TABLE custom_column_2 (id INTEGER PRIMARY KEY, book INTEGER, value TEXT);
+CREATE TABLE custom_column_3 (id INTEGER PRIMARY KEY, book INTEGER, value BOOL);
+CREATE TABLE custom_column_4 (id INTEGER PRIMARY KEY, book INTEGER, value INT);
+CREATE TABLE custom_column_5 (id INTEGER PRIMARY KEY, book INTEGER, value REAL);
+CREATE TABLE custom_column_6 (id INTEGER PRIMARY KEY, value INT);
+CREATE TABLE books_custom_column_6_link (id INTEGER PRIMARY KEY, book INTEGER, value INTEGER);
+CREATE TABLE custom_column_7 (id INTEGER PRIMARY KEY, book INTEGER, value timestamp);
+CREATE TABLE custom_column_8 (id INTEGER PRIMARY KEY, value TEXT, link TEXT DEFAULT '');
+CREATE TABLE books_custom_column_8_link (id INTEGER PRIMARY KEY, book INTEGER, value INTEGER);
+CREATE TABLE custom_column_9 (id INTEGER PRIMARY KEY, book INTEGER, value TEXT);
+CREATE TABLE custom_column_10 (id INTEGER PRIMARY KEY, value TEXT, link TEXT DEFAULT '');
+CREATE TABLE books_custom_column_10_link (id INTEGER PRIMARY KEY, book INTEGER, value INTEGER, extra REAL);
+CREATE TABLE custom_column_11 (id INTEGER PRIMARY KEY, value TEXT, link TEXT DEFAULT '');
+CREATE TABLE books_custom_column_11_link (id INTEGER PRIMARY KEY, book INTEGER, value INTEGER);
+CREATE TABLE preferences (id INTEGER PRIMARY KEY, key TEXT, val TEXT);
+
+INSERT INTO custom_columns (id, label, name, datatype, display, is_multiple, normalized) VALUES
+ (1, 'narrators', 'Narrators', 'text', '{"is_names": true}', 1, 1),
+ (2, 'duration', 'Duration', 'text', '{}', 0, 0),
+ (3, 'read', 'Read', 'bool', '{}', 0, 0),
+ (4, 'pages', 'Pages', 'int', '{"number_format": null}', 0, 0),
+ (5, 'price', 'Price', 'float', '{}', 0, 0),
+ (6, 'myrating', 'My Rating', 'rating', '{}', 0, 1),
+ (7, 'finished', 'Finished', 'datetime', '{}', 0, 0),
+ (8, 'genre', 'Genre', 'enumeration', '{"enum_values": ["Mystery", "Romance", "Horror"]}', 0, 1),
+ (9, 'notes', 'Notes', 'comments', '{"interpret_as": "markdown"}', 0, 0),
+ (10, 'subseries', 'Subseries', 'series', '{}', 0, 1),
+ (11, 'shelf', 'Shelf', 'text', '{}', 0, 1),
+ (12, 'summary', 'Summary', 'composite', '{"composite_template": "{title} by {authors}"}', 0, 0);
+
+INSERT INTO preferences (key, val) VALUES
+('field_metadata', '{
+ "authors": {"table": "authors", "column": "name", "link_column": "author", "datatype": "text", "is_multiple": {"cache_to_list": ",", "ui_to_list": "&", "list_to_ui": " & "}, "is_custom": false, "is_category": true, "is_editable": true, "label": "authors", "name": "Authors"},
+ "languages": {"table": "languages", "column": "lang_code", "link_column": "lang_code", "datatype": "text", "is_multiple": {"cache_to_list": ",", "ui_to_list": ",", "list_to_ui": ", "}, "is_custom": false, "is_category": true, "is_editable": true, "label": "languages", "name": "Languages"},
+ "tags": {"table": "tags", "column": "name", "link_column": "tag", "datatype": "text", "is_multiple": {"cache_to_list": ",", "ui_to_list": ",", "list_to_ui": ", "}, "is_custom": false, "is_category": true, "is_editable": true, "label": "tags", "name": "Tags"},
+ "publisher": {"table": "publishers", "column": "name", "link_column": "publisher", "datatype": "text", "is_multiple": {}, "is_custom": false, "is_category": true, "is_editable": true, "label": "publisher", "name": "Publisher"},
+ "series": {"table": "series", "column": "name", "link_column": "series", "datatype": "series", "is_multiple": {}, "is_custom": false, "is_category": true, "is_editable": true, "label": "series", "name": "Series"},
+ "rating": {"table": "ratings", "column": "rating", "link_column": "rating", "datatype": "rating", "is_multiple": {}, "is_custom": false, "is_category": true, "is_editable": true, "label": "rating", "name": "Rating"},
+ "formats": {"table": null, "column": null, "datatype": "text", "is_multiple": {"cache_to_list": ",", "ui_to_list": ",", "list_to_ui": ", "}, "is_custom": false, "is_category": true, "is_editable": true, "label": "formats", "name": "Formats"},
+ "identifiers": {"table": null, "column": null, "datatype": "text", "is_multiple": {"cache_to_list": ",", "ui_to_list": ",", "list_to_ui": ", "}, "is_custom": false, "is_category": true, "is_editable": true, "label": "identifiers", "name": "Identifiers"},
+ "title": {"table": null, "column": null, "datatype": "text", "is_multiple": {}, "is_custom": false, "is_category": false, "is_editable": true, "label": "title", "name": "Title"},
+ "#narrators": {"table": "custom_column_1", "column": "value", "link_column": "value", "datatype": "text", "is_multiple": {"cache_to_list": "|", "ui_to_list": "&", "list_to_ui": " & "}, "is_custom": true, "is_category": true, "is_editable": true, "label": "narrators", "name": "Narrators", "display": {"is_names": true}},
+ "#duration": {"table": "custom_column_2", "column": "value", "datatype": "text", "is_multiple": {}, "is_custom": true, "is_category": false, "is_editable": true, "label": "duration", "name": "Duration", "display": {}},
+ "#read": {"table": "custom_column_3", "column": "value", "datatype": "bool", "is_multiple": {}, "is_custom": true, "is_category": false, "is_editable": true, "label": "read", "name": "Read", "display": {}},
+ "#pages": {"table": "custom_column_4", "column": "value", "datatype": "int", "is_multiple": {}, "is_custom": true, "is_category": false, "is_editable": true, "label": "pages", "name": "Pages", "display": {}},
+ "#price": {"table": "custom_column_5", "column": "value", "datatype": "float", "is_multiple": {}, "is_custom": true, "is_category": false, "is_editable": true, "label": "price", "name": "Price", "display": {}},
+ "#myrating": {"table": "custom_column_6", "column": "value", "link_column": "value", "datatype": "rating", "is_multiple": {}, "is_custom": true, "is_category": true, "is_editable": true, "label": "myrating", "name": "Myrating", "display": {}},
+ "#finished": {"table": "custom_column_7", "column": "value", "datatype": "datetime", "is_multiple": {}, "is_custom": true, "is_category": false, "is_editable": true, "label": "finished", "name": "Finished", "display": {}},
+ "#genre": {"table": "custom_column_8", "column": "value", "link_column": "value", "datatype": "enumeration", "is_multiple": {}, "is_custom": true, "is_category": true, "is_editable": true, "label": "genre", "name": "Genre", "display": {}},
+ "#notes": {"table": "custom_column_9", "column": "value", "datatype": "comments", "is_multiple": {}, "is_custom": true, "is_category": false, "is_editable": true, "label": "notes", "name": "Notes", "display": {}},
+ "#subseries": {"table": "custom_column_10", "column": "value", "link_column": "value", "datatype": "series", "is_multiple": {}, "is_custom": true, "is_category": true, "is_editable": true, "label": "subseries", "name": "Subseries", "display": {}},
+ "#shelf": {"table": "custom_column_11", "column": "value", "link_column": "value", "datatype": "text", "is_multiple": {}, "is_custom": true, "is_category": true, "is_editable": true, "label": "shelf", "name": "Shelf", "display": {}},
+ "#summary": {"table": "custom_column_12", "column": "value", "datatype": "composite", "is_multiple": {}, "is_custom": true, "is_category": false, "is_editable": true, "label": "summary", "name": "Summary", "display": {}}
+}'),
+('saved_searches', '{"fantasy audio": "tags:fantasy and formats:m4b", "recent fantasy audio": "search:\"fantasy audio\" and pubdate:>=2010", "loop": "search:loop"}'),
+('book_display_fields', '[["title", true], ["authors", true], ["#duration", false]]'),
+('tag_browser_hidden_categories', '["languages"]'),
+('virtual_libraries', '{"Audiobooks": "formats:m4b", "Fantasy": "tags:\"=fantasy\" or search:\"fantasy audio\"", "Not Jane": "not authors:\"Jane Doe\" and rating:>=4"}');
+
+
+INSERT INTO authors (id, name, sort) VALUES
+ (1, 'Jane Doe', 'Jane Doe'),
+ (2, 'Al Bo', 'Al Bo'),
+ (3, 'Cy Ro', 'Cy Ro'),
+ (4, 'Émile Zola', 'Émile Zola'),
+ (5, 'anne bell', 'anne bell');
+INSERT INTO tags (id, name) VALUES
+ (1, 'fantasy'),
+ (2, 'sf'),
+ (3, 'thesis-sources'),
+ (4, 'history');
+INSERT INTO languages (id, lang_code) VALUES
+ (1, 'eng');
+INSERT INTO publishers (id, name, sort) VALUES
+ (1, 'Pub House', 'Pub House');
+INSERT INTO series (id, name, sort) VALUES
+ (1, 'Saga', 'Saga'),
+ (2, 'Épopée', 'Épopée'),
+ (3, 'Abenteuer', 'Abenteuer');
+INSERT INTO ratings (id, rating) VALUES
+ (1, 8);
+INSERT INTO custom_column_1 (id, value) VALUES
+ (1, 'Bob'),
+ (2, 'Ann');
+INSERT INTO custom_column_6 (id, value) VALUES
+ (1, 4),
+ (2, 10);
+INSERT INTO custom_column_8 (id, value) VALUES
+ (1, 'Mystery'),
+ (2, 'Horror');
+INSERT INTO custom_column_10 (id, value) VALUES
+ (1, 'Side Tales');
+INSERT INTO custom_column_11 (id, value) VALUES
+ (1, 'Attic');
+INSERT INTO books (id, title, sort, timestamp, pubdate, series_index, author_sort, path, uuid, has_cover, last_modified) VALUES
+ (1, 'Book 001', 'Book 001', '2022-01-02 10:00:00+00:00', '2001-03-04 00:00:00+00:00', 2, 'Al Bo', 'Author/Book 1 (1)', 'uuid-1', 1, '2022-02-01 00:00:00+00:00'),
+ (2, 'Book 002', 'Book 002', '2022-01-03 10:00:00+00:00', '2002-03-04 00:00:00+00:00', 3, 'Cy Ro', 'Author/Book 2 (2)', 'uuid-2', 1, '2022-02-01 00:00:00+00:00'),
+ (3, 'Book 003', 'Book 003', '2022-01-04 10:00:00+00:00', '2003-03-04 00:00:00+00:00', 4, 'Émile Zola', 'Author/Book 3 (3)', 'uuid-3', 1, '2022-02-01 00:00:00+00:00'),
+ (4, 'Book 004', 'Book 004', '2022-01-05 10:00:00+00:00', '2004-03-04 00:00:00+00:00', 5, 'anne bell', 'Author/Book 4 (4)', 'uuid-4', 1, '2022-02-01 00:00:00+00:00'),
+ (5, 'Book 005', 'Book 005', '2022-01-06 10:00:00+00:00', '2005-03-04 00:00:00+00:00', 1, 'Jane Doe', 'Author/Book 5 (5)', 'uuid-5', 1, '2022-02-01 00:00:00+00:00'),
+ (6, 'Book 006', 'Book 006', '2022-01-07 10:00:00+00:00', '2006-03-04 00:00:00+00:00', 2, 'Al Bo', 'Author/Book 6 (6)', 'uuid-6', 1, '2022-02-01 00:00:00+00:00'),
+ (7, 'Book 007', 'Book 007', '2022-01-08 10:00:00+00:00', '2007-03-04 00:00:00+00:00', 3, 'Cy Ro', 'Author/Book 7 (7)', 'uuid-7', 1, '2022-02-01 00:00:00+00:00'),
+ (8, 'Book 008', 'Book 008', '2022-01-09 10:00:00+00:00', '2008-03-04 00:00:00+00:00', 4, 'Émile Zola', 'Author/Book 8 (8)', 'uuid-8', 1, '2022-02-01 00:00:00+00:00'),
+ (9, 'Book 009', 'Book 009', '2022-01-10 10:00:00+00:00', '2009-03-04 00:00:00+00:00', 5, 'anne bell', 'Author/Book 9 (9)', 'uuid-9', 1, '2022-02-01 00:00:00+00:00'),
+ (10, 'Book 010', 'Book 010', '2022-01-11 10:00:00+00:00', '2010-03-04 00:00:00+00:00', 1, 'Jane Doe', 'Author/Book 10 (10)', 'uuid-10', 1, '2022-02-01 00:00:00+00:00'),
+ (11, 'Book 011', 'Book 011', '2022-01-12 10:00:00+00:00', '2011-03-04 00:00:00+00:00', 2, 'Al Bo', 'Author/Book 11 (11)', 'uuid-11', 1, '2022-02-01 00:00:00+00:00'),
+ (12, 'Book 012', 'Book 012', '2022-01-13 10:00:00+00:00', '2012-03-04 00:00:00+00:00', 3, 'Cy Ro', 'Author/Book 12 (12)', 'uuid-12', 1, '2022-02-01 00:00:00+00:00'),
+ (13, 'Book 013', 'Book 013', '2022-01-14 10:00:00+00:00', '2013-03-04 00:00:00+00:00', 4, 'Émile Zola', 'Author/Book 13 (13)', 'uuid-13', 1, '2022-02-01 00:00:00+00:00'),
+ (14, 'Book 014', 'Book 014', '2022-01-15 10:00:00+00:00', '2014-03-04 00:00:00+00:00', 5, 'anne bell', 'Author/Book 14 (14)', 'uuid-14', 1, '2022-02-01 00:00:00+00:00'),
+ (15, 'Book 015', 'Book 015', '2022-01-16 10:00:00+00:00', '2015-03-04 00:00:00+00:00', 1, 'Jane Doe', 'Author/Book 15 (15)', 'uuid-15', 1, '2022-02-01 00:00:00+00:00'),
+ (16, 'Book 016', 'Book 016', '2022-01-17 10:00:00+00:00', '2016-03-04 00:00:00+00:00', 2, 'Al Bo', 'Author/Book 16 (16)', 'uuid-16', 1, '2022-02-01 00:00:00+00:00'),
+ (17, 'Book 017', 'Book 017', '2022-01-18 10:00:00+00:00', '2017-03-04 00:00:00+00:00', 3, 'Cy Ro', 'Author/Book 17 (17)', 'uuid-17', 1, '2022-02-01 00:00:00+00:00'),
+ (18, 'Book 018', 'Book 018', '2022-01-19 10:00:00+00:00', '2018-03-04 00:00:00+00:00', 4, 'Émile Zola', 'Author/Book 18 (18)', 'uuid-18', 1, '2022-02-01 00:00:00+00:00'),
+ (19, 'Book 019', 'Book 019', '2022-01-20 10:00:00+00:00', '2019-03-04 00:00:00+00:00', 5, 'anne bell', 'Author/Book 19 (19)', 'uuid-19', 1, '2022-02-01 00:00:00+00:00'),
+ (20, 'Book 020', 'Book 020', '2022-01-21 10:00:00+00:00', '2000-03-04 00:00:00+00:00', 1, 'Jane Doe', 'Author/Book 20 (20)', 'uuid-20', 1, '2022-02-01 00:00:00+00:00'),
+ (21, 'Éclair', 'Éclair', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/Éclair (21)', 'uuid-21', 0, '2023-01-01 00:00:00+00:00'),
+ (22, 'Eagle', 'Eagle', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/Eagle (22)', 'uuid-22', 0, '2023-01-01 00:00:00+00:00'),
+ (23, 'écume', 'écume', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/écume (23)', 'uuid-23', 0, '2023-01-01 00:00:00+00:00'),
+ (24, 'Zèbre', 'Zèbre', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/Zèbre (24)', 'uuid-24', 0, '2023-01-01 00:00:00+00:00'),
+ (25, 'Ärger', 'Ärger', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/Ärger (25)', 'uuid-25', 0, '2023-01-01 00:00:00+00:00'),
+ (26, 'Apfel', 'Apfel', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/Apfel (26)', 'uuid-26', 0, '2023-01-01 00:00:00+00:00'),
+ (27, 'Öl', 'Öl', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/Öl (27)', 'uuid-27', 0, '2023-01-01 00:00:00+00:00'),
+ (28, 'Ozean', 'Ozean', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/Ozean (28)', 'uuid-28', 0, '2023-01-01 00:00:00+00:00'),
+ (29, '東京物語', '東京物語', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/東京物語 (29)', 'uuid-29', 0, '2023-01-01 00:00:00+00:00'),
+ (30, 'あさひ', 'あさひ', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/あさひ (30)', 'uuid-30', 0, '2023-01-01 00:00:00+00:00'),
+ (31, 'カメラ', 'カメラ', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/カメラ (31)', 'uuid-31', 0, '2023-01-01 00:00:00+00:00'),
+ (32, 'Юность', 'Юность', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/Юность (32)', 'uuid-32', 0, '2023-01-01 00:00:00+00:00'),
+ (33, 'Азбука', 'Азбука', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/Азбука (33)', 'uuid-33', 0, '2023-01-01 00:00:00+00:00'),
+ (34, 'ёлка', 'ёлка', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/ёлка (34)', 'uuid-34', 0, '2023-01-01 00:00:00+00:00'),
+ (35, '''Quoted', '''Quoted', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/''Quoted (35)', 'uuid-35', 0, '2023-01-01 00:00:00+00:00'),
+ (36, 'zebra', 'zebra', '2023-01-01 00:00:00+00:00', '2023-01-01 00:00:00+00:00', 1, 'Zola, Émile', 'Émile Zola/zebra (36)', 'uuid-36', 0, '2023-01-01 00:00:00+00:00');
+INSERT INTO books_authors_link (book, author) VALUES
+ (1, 2),
+ (2, 3),
+ (3, 4),
+ (4, 5),
+ (5, 1),
+ (6, 2),
+ (7, 3),
+ (8, 4),
+ (9, 5),
+ (10, 1),
+ (11, 2),
+ (12, 3),
+ (13, 4),
+ (14, 5),
+ (15, 1),
+ (16, 2),
+ (17, 3),
+ (18, 4),
+ (19, 5),
+ (20, 1),
+ (21, 4),
+ (22, 4),
+ (23, 4),
+ (24, 4),
+ (25, 4),
+ (26, 4),
+ (27, 4),
+ (28, 4),
+ (29, 4),
+ (30, 4),
+ (31, 4),
+ (32, 4),
+ (33, 4),
+ (34, 4),
+ (35, 4),
+ (36, 4);
+INSERT INTO books_tags_link (book, tag) VALUES
+ (1, 2),
+ (2, 3),
+ (3, 4),
+ (4, 1),
+ (5, 2),
+ (6, 3),
+ (7, 4),
+ (8, 1),
+ (9, 2),
+ (10, 3),
+ (11, 4),
+ (12, 1),
+ (13, 2),
+ (14, 3),
+ (15, 4),
+ (16, 1),
+ (17, 2),
+ (18, 3),
+ (19, 4),
+ (20, 1);
+INSERT INTO books_languages_link (book, lang_code) VALUES
+ (1, 1),
+ (2, 1),
+ (3, 1),
+ (4, 1),
+ (5, 1),
+ (6, 1),
+ (7, 1),
+ (8, 1),
+ (9, 1),
+ (10, 1),
+ (11, 1),
+ (12, 1),
+ (13, 1),
+ (14, 1),
+ (15, 1),
+ (16, 1),
+ (17, 1),
+ (18, 1),
+ (19, 1),
+ (20, 1);
+INSERT INTO books_series_link (book, series) VALUES
+ (3, 1),
+ (6, 1),
+ (9, 1),
+ (12, 1),
+ (15, 1),
+ (18, 1),
+ (21, 2),
+ (25, 3);
+INSERT INTO books_publishers_link (book, publisher) VALUES
+ (3, 1),
+ (6, 1),
+ (9, 1),
+ (12, 1),
+ (15, 1),
+ (18, 1);
+INSERT INTO books_ratings_link (book, rating) VALUES
+ (3, 1),
+ (6, 1),
+ (9, 1),
+ (12, 1),
+ (15, 1),
+ (18, 1);
+INSERT INTO data (book, format, uncompressed_size, name) VALUES
+ (1, 'EPUB', 1000, 'Book 1'),
+ (2, 'M4B', 2000, 'Book 2'),
+ (3, 'EPUB', 3000, 'Book 3'),
+ (4, 'M4B', 4000, 'Book 4'),
+ (5, 'EPUB', 5000, 'Book 5'),
+ (6, 'M4B', 6000, 'Book 6'),
+ (7, 'EPUB', 7000, 'Book 7'),
+ (8, 'M4B', 8000, 'Book 8'),
+ (9, 'EPUB', 9000, 'Book 9'),
+ (10, 'M4B', 10000, 'Book 10'),
+ (11, 'EPUB', 11000, 'Book 11'),
+ (12, 'M4B', 12000, 'Book 12'),
+ (13, 'EPUB', 13000, 'Book 13'),
+ (14, 'M4B', 14000, 'Book 14'),
+ (15, 'EPUB', 15000, 'Book 15'),
+ (16, 'M4B', 16000, 'Book 16'),
+ (17, 'EPUB', 17000, 'Book 17'),
+ (18, 'M4B', 18000, 'Book 18'),
+ (19, 'EPUB', 19000, 'Book 19'),
+ (20, 'M4B', 20000, 'Book 20');
+INSERT INTO identifiers (book, type, val) VALUES
+ (1, 'isbn', '9780000001'),
+ (2, 'isbn', '9780000002'),
+ (3, 'isbn', '9780000003'),
+ (4, 'isbn', '9780000004'),
+ (5, 'isbn', '9780000005'),
+ (6, 'isbn', '9780000006'),
+ (7, 'isbn', '9780000007'),
+ (8, 'isbn', '9780000008'),
+ (9, 'isbn', '9780000009'),
+ (10, 'isbn', '9780000010'),
+ (11, 'isbn', '9780000011'),
+ (12, 'isbn', '9780000012'),
+ (13, 'isbn', '9780000013'),
+ (14, 'isbn', '9780000014'),
+ (15, 'isbn', '9780000015'),
+ (16, 'isbn', '9780000016'),
+ (17, 'isbn', '9780000017'),
+ (18, 'isbn', '9780000018'),
+ (19, 'isbn', '9780000019'),
+ (20, 'isbn', '9780000020');
+INSERT INTO comments (book, text) VALUES
+ (1, '<p>About book 1</p>'),
+ (2, '<p>About book 2</p>'),
+ (3, '<p>About book 3</p>'),
+ (4, '<p>About book 4</p>'),
+ (5, '<p>About book 5</p>'),
+ (6, '<p>About book 6</p>'),
+ (7, '<p>About book 7</p>'),
+ (8, '<p>About book 8</p>'),
+ (9, '<p>About book 9</p>'),
+ (10, '<p>About book 10</p>'),
+ (11, '<p>About book 11</p>'),
+ (12, '<p>About book 12</p>'),
+ (13, '<p>About book 13</p>'),
+ (14, '<p>About book 14</p>'),
+ (15, '<p>About book 15</p>'),
+ (16, '<p>About book 16</p>'),
+ (17, '<p>About book 17</p>'),
+ (18, '<p>About book 18</p>'),
+ (19, '<p>About book 19</p>'),
+ (20, '<p>About book 20</p>');
+INSERT INTO books_custom_column_1_link (book, value) VALUES
+ (2, 1),
+ (4, 1),
+ (6, 1),
+ (8, 1),
+ (10, 1),
+ (12, 1),
+ (14, 1),
+ (16, 1),
+ (18, 1),
+ (20, 1);
+INSERT INTO custom_column_2 (book, value) VALUES
+ (2, '10:05:00'),
+ (4, '10:05:00'),
+ (6, '10:05:00'),
+ (8, '10:05:00'),
+ (10, '10:05:00'),
+ (12, '10:05:00'),
+ (14, '10:05:00'),
+ (16, '10:05:00'),
+ (18, '10:05:00'),
+ (20, '10:05:00');
+INSERT INTO custom_column_3 (book, value) VALUES
+ (4, 0),
+ (8, 1),
+ (12, 0),
+ (16, 1),
+ (20, 0);
+INSERT INTO custom_column_4 (book, value) VALUES
+ (4, 148),
+ (8, 296),
+ (12, 444),
+ (16, 592),
+ (20, 740);
+INSERT INTO custom_column_5 (book, value) VALUES
+ (4, 0.5),
+ (8, 1.0),
+ (12, 1.5),
+ (16, 2.0),
+ (20, 2.5);
+INSERT INTO books_custom_column_6_link (book, value) VALUES
+ (4, 2),
+ (8, 1),
+ (12, 2),
+ (16, 1),
+ (20, 2);
+INSERT INTO custom_column_7 (book, value) VALUES
+ (4, '2021-05-09 00:00:00+00:00'),
+ (8, '2021-09-09 00:00:00+00:00'),
+ (12, '2021-01-09 00:00:00+00:00'),
+ (16, '2021-05-09 00:00:00+00:00'),
+ (20, '2021-09-09 00:00:00+00:00');
+INSERT INTO books_custom_column_8_link (book, value) VALUES
+ (4, 2),
+ (8, 1),
+ (12, 2),
+ (16, 1),
+ (20, 2);
+INSERT INTO custom_column_9 (book, value) VALUES
+ (4, '*note* 4'),
+ (8, '*note* 8'),
+ (12, '*note* 12'),
+ (16, '*note* 16'),
+ (20, '*note* 20');
+INSERT INTO books_custom_column_10_link (book, value, extra) VALUES
+ (4, 1, 1.5),
+ (8, 1, 2.5),
+ (12, 1, 3.5),
+ (16, 1, 4.5),
+ (20, 1, 5.5);
+INSERT INTO books_custom_column_11_link (book, value) VALUES
+ (4, 1),
+ (8, 1),
+ (12, 1),
+ (16, 1),
+ (20, 1);
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ohzqq/urbooks-core/book"
+	"github.com/ohzqq/urbooks-core/bubbles"
+	"github.com/ohzqq/urbooks-core/urbooks"
+	"github.com/spf13/cobra"
+)
+
+var browseShowHidden bool
+
+// browseCmd represents the browse command
+var browseCmd = &cobra.Command{
+	Use:   "browse",
+	Short: "browse library",
+	Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		if lib == "" {
+			lib = urbooks.DefaultLib().Name
+		}
+		cmdLib = urbooks.Lib(lib)
+
+		browser := bubbles.NewBrowser(libSource{lib: cmdLib, showHidden: browseShowHidden})
+		for {
+			action, b := browser.Browse()
+			switch action {
+			case "edit":
+				if err := editBook(b); err != nil {
+					log.Println(err)
+				}
+				browser.Reload()
+			default:
+				return
+			}
+		}
+	},
+}
+
+// editBook opens the editor and writes the changed fields to the library.
+func editBook(b *book.Book) error {
+	editor := bubbles.NewEditor(b)
+	edited, ok := editor.Edit()
+	if !ok {
+		return nil
+	}
+
+	meta := make(map[string]string)
+	for _, field := range editor.Changed() {
+		meta[field] = edited.GetField(field).CliString()
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+
+	return urbooks.NewCalibredbCmd().
+		WithLib(lib).
+		Verbose(verbose).
+		SetMetadata(b.GetMeta("id"), meta)
+}
+
+type libSource struct {
+	lib        *urbooks.Library
+	showHidden bool
+}
+
+func (s libSource) Name() string {
+	return s.lib.Name
+}
+
+func (s libSource) Books(page int) (book.Books, int) {
+	resp := s.lib.GetBooks().Page(strconv.Itoa(page)).GetResponse()
+	return resp.ParseBooks().Books, lastPage(resp)
+}
+
+func (s libSource) CategoryBooks(label, id string, page int) (book.Books, int) {
+	resp := s.lib.NewRequest().From(label).ID(id).Page(strconv.Itoa(page)).GetResponse()
+	return resp.ParseBooks().Books, lastPage(resp)
+}
+
+func (s libSource) Category(label string) []*book.Item {
+	resp := s.lib.NewRequest().From(label).GetResponse()
+	var items []*book.Item
+	err := json.Unmarshal(resp.Data, &items)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return items
+}
+
+// HiddenCategories are the categories hidden in calibre's tag browser,
+// unless they're shown with --show-hidden.
+func (s libSource) HiddenCategories() []string {
+	if s.showHidden {
+		return nil
+	}
+	return s.lib.Pref.HiddenCategories
+}
+
+func (s libSource) Export(b *book.Book) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	return urbooks.NewCalibredbCmd().
+		WithLib(s.lib.Name).
+		Export(b.GetMeta("id"), dir, "")
+}
+
+// FilePath is the path of the book's audio file, or its first format.
+func (s libSource) FilePath(b *book.Book) string {
+	f := b.GetFile("audio")
+	if f.IsNull() {
+		if formats := b.GetField("formats").Collection().EachItem(); len(formats) > 0 {
+			f = formats[0]
+		}
+	}
+	if p := f.Get("path"); p != "" {
+		return filepath.Join(filepath.Dir(s.lib.Path), p)
+	}
+	return ""
+}
+
+func lastPage(resp urbooks.Response) int {
+	total, _ := strconv.Atoi(resp.GetResponseMeta("numberOfItems"))
+	perPage, err := strconv.Atoi(resp.GetResponseMeta("itemsPerPage"))
+	if err != nil || perPage == 0 {
+		return 1
+	}
+	last := (total + perPage - 1) / perPage
+	if last < 1 {
+		return 1
+	}
+	return last
+}
+
+func init() {
+	rootCmd.AddCommand(browseCmd)
+	browseCmd.Flags().BoolVar(&browseShowHidden, "show-hidden", false, "show the categories hidden in calibre")
+}
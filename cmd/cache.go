@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ohzqq/urbooks-core/audible"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "inspect cached audible responses",
+	Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		c := audible.ResponseCache()
+		entries, err := c.Entries()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var (
+			size    int64
+			expired int
+		)
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, e := range entries {
+			size += e.Size
+			status := "fresh"
+			if e.Expired() {
+				status = "expired"
+				expired++
+			}
+			if verbose {
+				fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", e.Kind, status, e.Age().Round(time.Minute), e.Size, e.URL)
+			}
+		}
+		w.Flush()
+
+		fmt.Printf("%v\n%d entries, %d expired, %d bytes\n", c.Dir(), len(entries), expired, size)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+}
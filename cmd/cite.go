@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ohzqq/urbooks-core/book"
+	"github.com/ohzqq/urbooks-core/urbooks"
+	"github.com/spf13/cobra"
+)
+
+var (
+	citeFormat   string
+	citeCategory string
+	citeOut      string
+)
+
+// citeCmd represents the cite command
+var citeCmd = &cobra.Command{
+	Use:   "cite [ids]",
+	Short: "export citations for books",
+	Long: `Export bibtex, ris or csl-json citations for books by id, for every book
+in a category with --category, eg tags:thesis-sources, or for the whole
+library.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if lib == "" {
+			lib = urbooks.DefaultLib().Name
+		}
+		cmdLib = urbooks.Lib(lib)
+
+		var books []*book.Book
+		switch {
+		case citeCategory != "":
+			books = categoryBooks(citeCategory)
+		case len(args) > 0:
+			cmdLib.GetBooks().Find(strings.Join(args, ",")).EachPage(func(resp urbooks.BookResponse) bool {
+				books = append(books, resp.Books...)
+				return true
+			})
+		default:
+			cmdLib.GetBooks().EachPage(func(resp urbooks.BookResponse) bool {
+				books = append(books, resp.Books...)
+				return true
+			})
+		}
+		if len(books) == 0 {
+			log.Fatal("no books to cite")
+		}
+
+		buf, err := book.Cite(citeFormat, books...)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var out io.Writer = os.Stdout
+		if citeOut != "" {
+			file, err := os.Create(citeOut)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer file.Close()
+			out = file
+		}
+		if _, err := buf.WriteTo(out); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// categoryBooks returns every book in a category given as label:name, the
+// name matches case insensitively.
+func categoryBooks(cat string) []*book.Book {
+	label, name, ok := strings.Cut(cat, ":")
+	if !ok {
+		log.Fatalf("%v is not a category, use label:name, eg tags:fiction\n", cat)
+	}
+
+	src := libSource{lib: cmdLib}
+	var id string
+	for _, i := range src.Category(label) {
+		if strings.EqualFold(i.Get("value"), name) {
+			id = i.Get("id")
+			break
+		}
+	}
+	if id == "" {
+		log.Fatalf("%v has no %v\n", label, name)
+	}
+
+	var books []*book.Book
+	for page, last := 1, 1; page <= last; page++ {
+		var b book.Books
+		b, last = src.CategoryBooks(label, id, page)
+		books = append(books, b...)
+	}
+	return books
+}
+
+func init() {
+	rootCmd.AddCommand(citeCmd)
+	citeCmd.Flags().StringVarP(&citeFormat, "format", "f", "bibtex", "bibtex, ris or csl-json")
+	citeCmd.Flags().StringVarP(&citeCategory, "category", "c", "", "cite every book in a category, eg tags:thesis-sources")
+	citeCmd.Flags().StringVarP(&citeOut, "output", "o", "", "file to write to instead of stdout")
+}
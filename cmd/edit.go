@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/ohzqq/urbooks-core/urbooks"
+	"github.com/spf13/cobra"
+)
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "edit a book's metadata",
+	Long:  ``,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if lib == "" {
+			lib = urbooks.DefaultLib().Name
+		}
+		cmdLib = urbooks.Lib(lib)
+
+		resp := cmdLib.GetBooks().Find(args[0]).GetResponse().ParseBooks()
+		if len(resp.Books) == 0 {
+			log.Fatalf("no book with id %v\n", args[0])
+		}
+		if err := editBook(resp.Books[0]); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+}
@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"fmt"
+	"log"
+
 	"github.com/ohzqq/urbooks-core/urbooks"
 	"github.com/spf13/cobra"
 )
@@ -20,10 +23,14 @@ var exportCmd = &cobra.Command{
 		if lib == "" {
 			lib = urbooks.DefaultLib().Name
 		}
-		urbooks.NewCalibredbCmd().
+		err := urbooks.NewCalibredbCmd().
 			WithLib(lib).
 			Verbose(verbose).
 			Export(args[0], dir, fmts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("export successful")
 	},
 }
 
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ohzqq/urbooks-core/urbooks"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+)
+
+const defaultMetaColumns = "id,title,authors,series,position,publisher,published,tags,rating,languages,identifiers,description"
+
+var (
+	metaCsv     bool
+	metaColumns string
+	metaOut     string
+)
+
+// exportMetaCmd represents the export-meta command
+var exportMetaCmd = &cobra.Command{
+	Use:   "export-meta --csv",
+	Short: "export library metadata as a csv spreadsheet",
+	Long: `Export one row per book. Collections are joined with " & " for names
+and ", " for everything else. The default columns are the builtin fields and
+every custom column of the library.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if lib == "" {
+			lib = urbooks.DefaultLib().Name
+		}
+		cmdLib = urbooks.Lib(lib)
+
+		if !metaCsv {
+			log.Fatal("csv is the only export format, use --csv")
+		}
+
+		columns := metaColumns
+		if columns == "" {
+			columns = defaultMetaColumns
+			var custom []string
+			for name := range cmdLib.CustomColumns {
+				custom = append(custom, name)
+			}
+			slices.Sort(custom)
+			for _, name := range custom {
+				columns += "," + name
+			}
+		}
+		fields := strings.Split(columns, ",")
+
+		var out io.Writer = os.Stdout
+		if metaOut != "" {
+			file, err := os.Create(metaOut)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		w := csv.NewWriter(out)
+		w.Write(fields)
+		cmdLib.GetBooks().EachPage(func(resp urbooks.BookResponse) bool {
+			for _, b := range resp.Books {
+				var row []string
+				for _, f := range fields {
+					row = append(row, fieldValue(b, f))
+				}
+				w.Write(row)
+			}
+			return true
+		})
+		w.Flush()
+		if err := w.Error(); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportMetaCmd)
+	exportMetaCmd.Flags().BoolVar(&metaCsv, "csv", false, "export as csv")
+	exportMetaCmd.MarkFlagRequired("csv")
+	exportMetaCmd.Flags().StringVarP(&metaColumns, "columns", "c", "", "comma separated columns (default builtin fields and all custom columns)")
+	exportMetaCmd.Flags().StringVarP(&metaOut, "output", "o", "", "file to write to instead of stdout")
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ohzqq/urbooks-core/book"
+	"github.com/ohzqq/urbooks-core/urbooks"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+)
+
+// importMetaCmd represents the import-meta command
+var importMetaCmd = &cobra.Command{
+	Use:   "import-meta <file.csv>",
+	Short: "update library metadata from a spreadsheet",
+	Long: `Compare each row of a csv, as written by export-meta, with the book of
+its id and set the fields that changed. Empty cells clear a field.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if lib == "" {
+			lib = urbooks.DefaultLib().Name
+		}
+		cmdLib = urbooks.Lib(lib)
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+
+		rows, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(rows) < 2 {
+			log.Fatalf("%v has no books\n", args[0])
+		}
+
+		header := rows[0]
+		idCol := slices.Index(header, "id")
+		if idCol < 0 {
+			log.Fatalf("%v has no id column\n", args[0])
+		}
+
+		var ids []string
+		for _, row := range rows[1:] {
+			ids = append(ids, row[idCol])
+		}
+		books := make(map[string]*book.Book)
+		cmdLib.GetBooks().Find(strings.Join(ids, ",")).EachPage(func(resp urbooks.BookResponse) bool {
+			for _, b := range resp.Books {
+				books[b.GetMeta("id")] = b
+			}
+			return true
+		})
+
+		var changed int
+		for _, row := range rows[1:] {
+			id := row[idCol]
+			local, ok := books[id]
+			if !ok {
+				log.Printf("no book with id %v\n", id)
+				continue
+			}
+
+			edited, diffs := rowChanges(local, header, row)
+			if len(diffs) == 0 {
+				continue
+			}
+			if err := applyRow(local, edited, diffs); err != nil {
+				log.Printf("failed to update %v: %v\n", id, err)
+				continue
+			}
+			changed++
+		}
+
+		switch {
+		case changed == 0:
+			fmt.Println("no changes")
+		case dryRun:
+			fmt.Printf("%v books would be updated\n", changed)
+		default:
+			fmt.Printf("updated %v books\n", changed)
+		}
+	},
+}
+
+// rowChanges sets the cells of the row on a copy of the book, returning the
+// copy and the fields that changed.
+func rowChanges(local *book.Book, header, row []string) (*book.Book, []book.FieldDiff) {
+	edited := local.Copy()
+	for i, name := range header {
+		field := edited.GetField(name)
+		if field == nil || !field.IsEditable || name == "id" {
+			continue
+		}
+		if row[i] == field.String() {
+			continue
+		}
+		if err := field.Validate(row[i]); err != nil {
+			log.Printf("%v: %v\n", local.GetMeta("id"), err)
+			continue
+		}
+		field.SetString(row[i])
+	}
+	return edited, book.Diff(local, edited)
+}
+
+func applyRow(local, edited *book.Book, diffs []book.FieldDiff) error {
+	fmt.Printf("%v: %v\n", local.GetMeta("id"), local.GetMeta("title"))
+
+	meta := make(map[string]string)
+	for _, d := range diffs {
+		printDiff(d)
+		meta[d.Field] = edited.GetField(d.Field).CliString()
+	}
+
+	if dryRun {
+		return nil
+	}
+	return urbooks.NewCalibredbCmd().
+		WithLib(lib).
+		Verbose(verbose).
+		SetMetadata(local.GetMeta("id"), meta)
+}
+
+func init() {
+	rootCmd.AddCommand(importMetaCmd)
+	importMetaCmd.Flags().BoolVar(&dryRun, "dry-run", false, "report the changes without updating the library")
+}
@@ -1,10 +1,19 @@
 package cmd
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/ohzqq/urbooks-core/book"
 	"github.com/ohzqq/urbooks-core/urbooks"
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
 )
 
 const (
@@ -22,71 +31,215 @@ const (
 )
 
 var (
-	cmdLib *urbooks.Library
-	//req          *urbooks.request
+	cmdLib       *urbooks.Library
 	searchFields = make([]string, 11)
+	lsFields     string
+	lsOutput     string
+	lsVL         string
+	lsLocale     string
+	lsSaved      string
 )
 
+// filterFields are the calibre search fields of the flags that filter
+// books, by searchFields index.
+var filterFields = map[int]string{
+	authors:   "authors",
+	added:     "added",
+	narrators: "#narrators",
+	published: "published",
+	publisher: "publisher",
+	rating:    "rating",
+	series:    "series",
+	tags:      "tags",
+}
+
 // lsCmd represents the ls command
 var lsCmd = &cobra.Command{
 	Use:   "ls",
 	Short: "list books in library",
 	Long:  ``,
 	Run: func(cmd *cobra.Command, args []string) {
+		if lib == "" {
+			lib = urbooks.DefaultLib().Name
+		}
 		cmdLib = urbooks.Lib(lib)
-		//req = buildRequest(args)
-		//somebooks()
-		fmt.Printf("%+v\n", searchFields[8])
+
+		out := lsOutput
+		if !slices.Contains([]string{"table", "json", "csv"}, out) && !slices.Contains(book.ListFormats(), out) {
+			log.Fatalf("%v is not an output format, use table, json, csv or one of %v\n", out, book.ListFormats())
+		}
+
+		max, err := strconv.Atoi(searchFields[limit])
+		if err != nil && searchFields[limit] != "" {
+			log.Fatalf("limit %v is not a number\n", searchFields[limit])
+		}
+
+		books := listBooks(max)
+
+		fields := strings.Split(lsFields, ",")
+		switch out {
+		case "table":
+			printTable(books, fields)
+		case "json":
+			printJson(books, fields)
+		case "csv":
+			printCsv(books, fields)
+		default:
+			for _, b := range books {
+				if err := b.ConvertTo(out).Print(); err != nil {
+					log.Fatal(err)
+				}
+			}
+		}
 	},
 }
 
-//func buildRequest(args []string) *urbooks.Request {
-//return cmdLib.Books().Limit("1")
-//return cmdLib.DefaultRequest
-//if len(args) != 0 {
-//req = buildRequest(args)
-//}
-//return req
-//}
+// listBooks pages through the books found by the search flags, up to max
+// books if max isn't 0.
+func listBooks(max int) []*book.Book {
+	req := cmdLib.GetBooks()
+	if s := searchFields[sort]; s != "" {
+		req.Sort(s)
+	}
+	if o := searchFields[order]; o != "" {
+		req.Order(o)
+	}
+	if lsVL != "" {
+		req.VirtualLibrary(lsVL)
+	}
+	if lsSaved != "" {
+		req.From("searches").ID(lsSaved)
+	}
+	if lsLocale != "" {
+		req.Locale(lsLocale)
+	}
+	if expr := searchExpr(); expr != "" {
+		req.Search(expr)
+	}
+	if max > 0 {
+		req.Limit(strconv.Itoa(max))
+	}
+
+	var books []*book.Book
+	req.EachPage(func(resp urbooks.BookResponse) bool {
+		for _, e := range resp.ResponseErrors {
+			log.Fatalf("%v: %v\n", e["status"], e["detail"])
+		}
+		for _, b := range resp.Books {
+			books = append(books, b)
+			if max > 0 && len(books) >= max {
+				return false
+			}
+		}
+		return true
+	})
+	return books
+}
+
+// searchExpr is a calibre search for the search flags, each flag's value is
+// matched against its field and they all have to match.
+func searchExpr() string {
+	var idxs []int
+	for idx := range filterFields {
+		if searchFields[idx] != "" {
+			idxs = append(idxs, idx)
+		}
+	}
+	slices.Sort(idxs)
+
+	var terms []string
+	for _, idx := range idxs {
+		val := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(searchFields[idx])
+		terms = append(terms, fmt.Sprintf(`%s:"%s"`, filterFields[idx], val))
+	}
+	return strings.Join(terms, " and ")
+}
 
 func somebooks() {
 	req := cmdLib.GetBooks().Limit("1").GetResponse()
 	books := req.ParseBooks()
 	for _, b := range books.Books {
 		println(b.GetMeta("title"))
-		//for label, f := range b.EachField() {
-		//  fmt.Printf("field: %v\n", label)
-		//  fmt.Printf("data %+V\n", f.String())
-		//}
 	}
 }
 
-//func somecat(r *urbooks.request) {
-//  resp := makeReq(r)
-//  fmt.Println(string(resp))
-//}
+func fieldValue(b *book.Book, name string) string {
+	if f := b.GetField(name); f != nil {
+		return f.String()
+	}
+	return ""
+}
+
+func printTable(books []*book.Book, fields []string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(fields, "\t"))
+	for _, b := range books {
+		var row []string
+		for _, f := range fields {
+			row = append(row, fieldValue(b, f))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+func printCsv(books []*book.Book, fields []string) {
+	w := csv.NewWriter(os.Stdout)
+	w.Write(fields)
+	for _, b := range books {
+		var row []string
+		for _, f := range fields {
+			row = append(row, fieldValue(b, f))
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func printJson(books []*book.Book, fields []string) {
+	var data []map[string]any
+	for _, b := range books {
+		m := make(map[string]any)
+		for _, name := range fields {
+			f := b.GetField(name)
+			switch {
+			case f == nil:
+				m[name] = nil
+			case f.IsCollection():
+				m[name] = f.Collection().StringSlice()
+			default:
+				m[name] = f.String()
+			}
+		}
+		data = append(data, m)
+	}
 
-//func makeReq(r *urbooks.request) []byte {
-//  resp, err := urbooks.Get(r.String())
-//  if err != nil {
-//    log.Fatal(err)
-//  }
-//  return resp
-//}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		log.Fatal(err)
+	}
+}
 
 func init() {
 	rootCmd.AddCommand(lsCmd)
 	lsCmd.PersistentFlags().StringVarP(&searchFields[authors], "authors", "a", "", "author field")
-	//lsCmd.PersistentFlags().StringVarP(&authorsFlag, "authors", "a", "", "author field")
 	lsCmd.PersistentFlags().StringVarP(&searchFields[added], "added", "d", "", "date added field")
-	//  lsCmd.PersistentFlags().StringVarP(&limitFlag, "limit", "l", "", "limit results")
-	//  lsCmd.PersistentFlags().StringVarP(&narratorsFlag, "narrators", "n", "", "narrator field")
-	//  lsCmd.PersistentFlags().StringVarP(&orderFlag, "order", "O", "", "order of results (asc or desc)")
-	//  lsCmd.PersistentFlags().StringVarP(&publishedFlag, "published", "p", "", "date published")
-	//  lsCmd.PersistentFlags().StringVarP(&publisherFlag, "publisher", "P", "", "publisher field")
-	//  lsCmd.PersistentFlags().StringVarP(&ratingFlag, "rating", "r", "", "rating field")
-	//  lsCmd.PersistentFlags().StringVarP(&seriesFlag, "series", "s", "", "series field")
-	//  lsCmd.PersistentFlags().StringVarP(&sortFlag, "sort", "S", "", "sort results by...")
-	//  lsCmd.PersistentFlags().StringVarP(&tagsFlag, "tags", "t", "", "tags field")
-	//  lsCmd.PersistentFlags().StringVarP(&titleFlag, "title", "T", "", "title field")
+	lsCmd.PersistentFlags().StringVarP(&searchFields[limit], "limit", "L", "", "limit results")
+	lsCmd.PersistentFlags().StringVarP(&searchFields[narrators], "narrators", "n", "", "narrator field")
+	lsCmd.PersistentFlags().StringVarP(&searchFields[order], "order", "O", "", "order of results (asc or desc)")
+	lsCmd.PersistentFlags().StringVarP(&searchFields[published], "published", "p", "", "date published")
+	lsCmd.PersistentFlags().StringVarP(&searchFields[publisher], "publisher", "P", "", "publisher field")
+	lsCmd.PersistentFlags().StringVarP(&searchFields[rating], "rating", "r", "", "rating field")
+	lsCmd.PersistentFlags().StringVarP(&searchFields[series], "series", "s", "", "series field")
+	lsCmd.PersistentFlags().StringVarP(&searchFields[sort], "sort", "S", "", "sort results by...")
+	lsCmd.PersistentFlags().StringVarP(&searchFields[tags], "tags", "t", "", "tags field")
+	lsCmd.PersistentFlags().StringVarP(&lsFields, "fields", "f", "title,authors,series", "comma separated fields to print")
+	lsCmd.PersistentFlags().StringVar(&lsVL, "vl", "", "only list books in a calibre virtual library")
+	lsCmd.PersistentFlags().StringVar(&lsSaved, "saved", "", "only list books found by a calibre saved search")
+	lsCmd.PersistentFlags().StringVar(&lsLocale, "locale", "", "sort titles and names for a locale, eg fr")
+	lsCmd.PersistentFlags().StringVarP(&lsOutput, "output", "o", "table", "table, json, csv or a metadata format")
 }
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/ohzqq/urbooks-core/audible"
+	"github.com/ohzqq/urbooks-core/book"
+	"github.com/ohzqq/urbooks-core/bubbles"
+	"github.com/ohzqq/urbooks-core/urbooks"
+	"github.com/spf13/cobra"
+)
+
+var (
+	acceptScore float64
+	minScore    float64
+	maxChoices  int
+	dryRun      bool
+)
+
+type pendingMatch struct {
+	book    *book.Book
+	matches []audible.Match
+}
+
+// matchCmd represents the match command
+var matchCmd = &cobra.Command{
+	Use:   "match",
+	Short: "find audible asins for books in library",
+	Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		if lib == "" {
+			lib = urbooks.DefaultLib().Name
+		}
+		cmdLib = urbooks.Lib(lib)
+		audible.ResponseCache().SetRefresh(refresh)
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		resp := cmdLib.GetBooks().Find("all").GetResponse().ParseBooks()
+
+		var queue []pendingMatch
+		for _, b := range resp.Books {
+			if ctx.Err() != nil {
+				break
+			}
+			if b.GetIdentifier("audible") != "" {
+				continue
+			}
+
+			matches, err := findMatches(ctx, b)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v: %v\n", b.GetMeta("title"), err)
+				continue
+			}
+
+			switch {
+			case len(matches) == 0 || matches[0].Score < minScore:
+				if verbose {
+					fmt.Printf("no match for %v\n", b.GetMeta("title"))
+				}
+			case matches[0].Score >= acceptScore:
+				setAsin(b, matches[0])
+			default:
+				if len(matches) > maxChoices {
+					matches = matches[:maxChoices]
+				}
+				queue = append(queue, pendingMatch{book: b, matches: matches})
+			}
+		}
+
+		for _, p := range queue {
+			if i, ok := confirmMatch(p); ok {
+				setAsin(p.book, p.matches[i])
+			}
+		}
+	},
+}
+
+// findMatches searches audible by title, author and narrator, dropping the
+// narrator if that finds nothing.
+func findMatches(ctx context.Context, b *book.Book) ([]audible.Match, error) {
+	search := func(narrators string) ([]*book.Book, error) {
+		q := audible.NewQuery().WithContext(ctx)
+		q.SetTitle(b.GetMeta("title"))
+		q.SetAuthors(firstValue(b, "authors"))
+		q.SetNarrators(narrators)
+		return q.Candidates()
+	}
+
+	narrators := firstValue(b, "#narrators")
+	candidates, err := search(narrators)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 && narrators != "" {
+		candidates, err = search("")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return audible.Rank(b, candidates), nil
+}
+
+func firstValue(b *book.Book, field string) string {
+	f := b.GetField(field)
+	if f == nil || f.IsNull() {
+		return ""
+	}
+	if f.IsCollection() {
+		if vals := f.Collection().StringSlice(); len(vals) > 0 {
+			return vals[0]
+		}
+		return ""
+	}
+	return f.String()
+}
+
+// confirmMatch returns the index of the chosen match, it's false when the
+// book is skipped.
+func confirmMatch(p pendingMatch) (int, bool) {
+	var choices []bubbles.Choice
+	for i, m := range p.matches {
+		choices = append(choices, bubbles.Choice{
+			ID: strconv.Itoa(i),
+			Title: fmt.Sprintf(
+				"%.2f %s by %s, read by %s (%s)",
+				m.Score,
+				m.Book.GetMeta("title"),
+				m.Book.GetMeta("authors"),
+				m.Book.GetMeta("#narrators"),
+				m.Book.GetMeta("#duration"),
+			),
+		})
+	}
+	choices = append(choices, bubbles.Choice{ID: "skip", Title: "skip"})
+
+	title := fmt.Sprintf("match %v by %v", p.book.GetMeta("title"), p.book.GetMeta("authors"))
+	choice := bubbles.NewChoicePrompt(title, choices).Choose()
+
+	i, err := strconv.Atoi(choice)
+	if err != nil {
+		return 0, false
+	}
+	return i, true
+}
+
+// setAsin appends the asin to the book's identifiers, calibre replaces all of
+// them with set_metadata so the existing ones are written back too.
+func setAsin(b *book.Book, m audible.Match) {
+	asin := m.ASIN()
+	if asin == "" {
+		return
+	}
+
+	var ids []string
+	for _, i := range b.GetField("identifiers").Collection().EachItem() {
+		if v := i.Get("value"); v != "" {
+			ids = append(ids, v)
+		}
+	}
+	ids = append(ids, "audible:"+asin)
+
+	if dryRun {
+		fmt.Printf("%v: audible:%v (%.2f)\n", b.GetMeta("title"), asin, m.Score)
+		return
+	}
+
+	err := urbooks.NewCalibredbCmd().
+		WithLib(lib).
+		Verbose(verbose).
+		SetMetadata(b.GetMeta("id"), map[string]string{"identifiers": strings.Join(ids, ",")})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(matchCmd)
+
+	matchCmd.Flags().Float64Var(&acceptScore, "accept", 0.85, "accept matches scoring at least this without asking")
+	matchCmd.Flags().Float64Var(&minScore, "min", 0.5, "ignore matches scoring below this")
+	matchCmd.Flags().IntVar(&maxChoices, "choices", 5, "number of candidates to choose from")
+	matchCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print matches without updating the library")
+	matchCmd.Flags().BoolVar(&refresh, "refresh", false, "ignore cached responses and fetch them again")
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ohzqq/urbooks-core/audible"
+	"github.com/spf13/cobra"
+)
+
+var pruneAll bool
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "remove expired responses from the cache",
+	Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		n, err := audible.ResponseCache().Prune(pruneAll)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("removed %d entries\n", n)
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().BoolVarP(&pruneAll, "all", "a", false, "remove every cached response")
+}
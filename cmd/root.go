@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/ohzqq/urbooks-core/audible"
 	"github.com/ohzqq/urbooks-core/urbooks"
 
 	"github.com/spf13/cobra"
@@ -70,6 +71,8 @@ func initConfig() {
 		urbooks.InitLibraries(viper.Sub("libraries"), false)
 
 		urbooks.CfgCdb(viper.Sub("calibre"))
+		urbooks.CfgFormats(viper.Sub("formats"))
+		audible.Config(viper.Sub("audible"))
 		if lib == "" {
 			lib = urbooks.DefaultLib().Name
 		}
@@ -1,6 +1,12 @@
 package cmd
 
 import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
 	"github.com/ohzqq/urbooks-core/audible"
 	"github.com/ohzqq/urbooks-core/book"
 	"github.com/spf13/cobra"
@@ -10,6 +16,13 @@ var (
 	audibleUrl string
 	batchUrl   string
 	noCovers   bool
+	noProgress bool
+	refresh    bool
+	chapters   string
+	timeout    time.Duration
+	retries    int
+	workers    int
+	rps        float64
 	query      = audible.NewQuery()
 )
 
@@ -20,30 +33,79 @@ var scrapeCmd = &cobra.Command{
 	Long:  ``,
 	Run: func(cmd *cobra.Command, args []string) {
 		query.SetKeywords(args)
-		apicall()
+		if chapters != "" {
+			if chapters != "ffmeta" && chapters != "cue" {
+				log.Fatalf("%v is not a chapter format, use ffmeta or cue\n", chapters)
+			}
+			query.SetChapters(true)
+		}
+		if cmd.Flags().Changed("timeout") {
+			audible.SetTimeout(timeout)
+		}
+		if cmd.Flags().Changed("retries") {
+			audible.SetRetries(retries)
+		}
+		if cmd.Flags().Changed("workers") {
+			audible.SetConcurrency(workers)
+		}
+		if cmd.Flags().Changed("rps") {
+			audible.SetRequestsPerSecond(rps)
+		}
+		query.SetProgress(!noProgress)
+		audible.ResponseCache().SetRefresh(refresh)
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+		query.WithContext(ctx)
+
+		apicall(ctx)
 	},
 }
 
-func apicall() {
-	var books []*book.Book
+func apicall(ctx context.Context) {
+	var (
+		books []*book.Book
+		err   error
+	)
 
 	switch {
 	case audibleUrl != "":
 		query.SetUrl(audibleUrl)
-		books = append(books, query.GetBook())
+		var b *book.Book
+		b, err = query.GetBook()
+		if b != nil {
+			books = append(books, b)
+		}
 	case batchUrl != "":
 		query.IsBatch = true
 		query.SetUrl(batchUrl)
-		books = query.GetBookBatch()
+		books, err = query.GetBookBatch()
 	case query.Keywords != "":
-		books = query.Search()
+		books, err = query.Search()
 	}
 
+	// write whatever was scraped before failing
+	defer func() {
+		if err != nil {
+			log.Fatal(err)
+		}
+	}()
+
 	for _, b := range books {
-		b.ConvertTo("toml").Write()
+		if err := b.ConvertTo("toml").Write(); err != nil {
+			log.Println(err)
+		}
+		if chapters != "" && len(b.Chapters()) > 0 {
+			if err := b.ConvertTo(chapters).Write(); err != nil {
+				log.Println(err)
+			}
+		}
 		if !noCovers {
 			u := b.GetFile("cover").Get("url")
-			audible.DownloadCover(b.GetField("title").String(), u)
+			err := audible.DownloadCover(ctx, b.GetField("title").String(), u)
+			if err != nil {
+				log.Println(err)
+			}
 		}
 	}
 }
@@ -52,6 +114,13 @@ func init() {
 	rootCmd.AddCommand(scrapeCmd)
 
 	scrapeCmd.Flags().BoolVar(&noCovers, "nc", false, "don't download covers")
+	scrapeCmd.Flags().BoolVar(&noProgress, "np", false, "don't show a progress bar")
+	scrapeCmd.Flags().BoolVar(&refresh, "refresh", false, "ignore cached responses and fetch them again")
+	scrapeCmd.Flags().StringVarP(&chapters, "chapters", "c", "", "fetch chapters and write them as ffmeta or cue")
+	scrapeCmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "timeout for each request to audible")
+	scrapeCmd.Flags().IntVar(&retries, "retries", 3, "retries for failed or rate limited requests")
+	scrapeCmd.Flags().IntVarP(&workers, "workers", "w", 4, "number of concurrent requests")
+	scrapeCmd.Flags().Float64Var(&rps, "rps", 5, "max requests per second, 0 for no limit")
 
 	scrapeCmd.Flags().StringVarP(&audibleUrl, "url", "u", "", "audible url")
 	scrapeCmd.Flags().StringVarP(&batchUrl, "batch", "b", "", "batch scrape from audible search list")
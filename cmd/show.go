@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ohzqq/urbooks-core/book"
+	"github.com/ohzqq/urbooks-core/bubbles"
+	"github.com/ohzqq/urbooks-core/urbooks"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	showFormat   string
+	showTemplate string
+)
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "show a book's metadata",
+	Long:  `Show a book as a markdown card, in any metadata format with --format, or with a go template file with --template.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if lib == "" {
+			lib = urbooks.DefaultLib().Name
+		}
+		cmdLib = urbooks.Lib(lib)
+
+		if showTemplate == "" && !slices.Contains(book.ListFormats(), showFormat) {
+			log.Fatalf("%v is not a format, use one of %v\n", showFormat, book.ListFormats())
+		}
+
+		books := cmdLib.From("books").ID(args[0]).GetResponse().Books
+		if len(books) == 0 {
+			log.Fatalf("no book with id %v\n", args[0])
+		}
+		b := books[0]
+		fullPaths(b)
+
+		switch {
+		case showTemplate != "":
+			tmpl, err := os.ReadFile(showTemplate)
+			if err != nil {
+				log.Fatal(err)
+			}
+			buf, err := b.ExecuteTemplate(string(tmpl))
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(buf.String())
+		case showFormat == "card":
+			buf, err := b.ConvertTo("card").Render()
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(bubbles.RenderMarkdown(buf.String()))
+		default:
+			if err := b.ConvertTo(showFormat).Print(); err != nil {
+				log.Fatal(err)
+			}
+		}
+	},
+}
+
+// fullPaths makes the cover and format paths, which are relative to the
+// library's parent directory, absolute.
+func fullPaths(b *book.Book) {
+	dir := filepath.Dir(cmdLib.Path)
+	items := []*book.Item{b.GetFile("cover")}
+	if f := b.GetField("formats"); !f.IsNull() {
+		items = append(items, f.Collection().EachItem()...)
+	}
+	for _, i := range items {
+		if p := i.Get("path"); p != "" && !filepath.IsAbs(p) {
+			i.Set("path", filepath.Join(dir, p))
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(showCmd)
+	showCmd.Flags().StringVarP(&showFormat, "format", "f", "card", "metadata format")
+	showCmd.Flags().StringVarP(&showTemplate, "template", "T", "", "go template file to render the book with")
+}
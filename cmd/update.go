@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ohzqq/urbooks-core/audible"
+	"github.com/ohzqq/urbooks-core/book"
+	"github.com/ohzqq/urbooks-core/bubbles"
+	"github.com/ohzqq/urbooks-core/urbooks"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateFrom   string
+	updatePolicy string
+	acceptAll    bool
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update <id>",
+	Short: "update a library book with metadata from audible",
+	Long:  ``,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if lib == "" {
+			lib = urbooks.DefaultLib().Name
+		}
+		cmdLib = urbooks.Lib(lib)
+
+		if updatePolicy != book.PreferLibrary && updatePolicy != book.PreferRemote {
+			log.Fatalf("%v is not a merge policy, use library or remote\n", updatePolicy)
+		}
+
+		resp := cmdLib.GetBooks().Find(args[0]).GetResponse().ParseBooks()
+		if len(resp.Books) == 0 {
+			log.Fatalf("no book with id %v\n", args[0])
+		}
+		local := resp.Books[0]
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		scheme, asin, _ := strings.Cut(updateFrom, ":")
+		if scheme != "audible" || asin == "" {
+			log.Fatalf("%v is not a source, use audible:<asin>\n", updateFrom)
+		}
+		q := audible.NewQuery().WithContext(ctx)
+		q.SetUrl("https://www.audible.com/pd/" + asin)
+		remote, err := q.GetBook()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var diffs []book.FieldDiff
+		for _, d := range book.Diff(local, remote) {
+			if local.GetField(d.Field) != nil {
+				diffs = append(diffs, d)
+			}
+		}
+		if len(diffs) == 0 {
+			fmt.Println("no changes")
+			return
+		}
+
+		for _, d := range diffs {
+			printDiff(d)
+		}
+
+		policy := book.NewMergePolicy(updatePolicy)
+		if !acceptAll {
+			for _, d := range diffs {
+				policy.Set(d.Field, chooseStrategy(d))
+			}
+		}
+
+		merged := book.Merge(local, remote, policy)
+		meta := make(map[string]string)
+		for _, d := range diffs {
+			if policy.Get(d.Field) == book.PreferLibrary {
+				continue
+			}
+			meta[d.Field] = merged.GetField(d.Field).CliString()
+		}
+
+		if len(meta) == 0 || dryRun {
+			return
+		}
+
+		err = urbooks.NewCalibredbCmd().
+			WithLib(lib).
+			Verbose(verbose).
+			SetMetadata(local.GetMeta("id"), meta)
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func printDiff(d book.FieldDiff) {
+	var (
+		field   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(bubbles.Theme.Purple))
+		removed = lipgloss.NewStyle().Foreground(lipgloss.Color(bubbles.Theme.Red))
+		added   = lipgloss.NewStyle().Foreground(lipgloss.Color(bubbles.Theme.Green))
+	)
+
+	fmt.Println(field.Render(d.Field))
+	if d.IsMultiple {
+		for _, v := range d.Removed {
+			fmt.Println(removed.Render("- " + v))
+		}
+		for _, v := range d.Added {
+			fmt.Println(added.Render("+ " + v))
+		}
+		return
+	}
+	if d.Library != "" {
+		fmt.Println(removed.Render("- " + d.Library))
+	}
+	if d.Remote != "" {
+		fmt.Println(added.Render("+ " + d.Remote))
+	}
+}
+
+func chooseStrategy(d book.FieldDiff) string {
+	choices := map[string]string{
+		"keep library": book.PreferLibrary,
+		"use audible":  book.PreferRemote,
+	}
+	if d.IsMultiple {
+		choices["merge both"] = book.Union
+	}
+	if choice := bubbles.NewPrompt(d.Field, choices).Choose(); choice != "" {
+		return choice
+	}
+	return book.PreferLibrary
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	updateCmd.Flags().StringVarP(&updateFrom, "from", "f", "", "where to get metadata from, eg audible:<asin>")
+	updateCmd.MarkFlagRequired("from")
+	updateCmd.Flags().StringVarP(&updatePolicy, "policy", "p", book.PreferRemote, "merge policy for fields, library or remote")
+	updateCmd.Flags().BoolVarP(&acceptAll, "yes", "y", false, "apply the merge policy without asking about each field")
+	updateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "show the diff without updating the library")
+}
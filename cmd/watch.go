@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ohzqq/urbooks-core/urbooks"
+	"github.com/spf13/cobra"
+)
+
+var watchAddr string
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "print the books added, modified or removed from a library",
+	Long: `Print each change to the library as a json line. With --addr the changes
+are served as server-sent events at /api/events?library= instead, and the
+library's response cache stats as json at /api/cache?library=.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if watchAddr != "" {
+			http.HandleFunc("/api/events", urbooks.EventsHandler)
+			http.HandleFunc("/api/cache", urbooks.CacheHandler)
+			log.Fatal(http.ListenAndServe(watchAddr, nil))
+		}
+
+		if lib == "" {
+			lib = urbooks.DefaultLib().Name
+		}
+		cmdLib = urbooks.Lib(lib)
+
+		for c := range cmdLib.Subscribe(context.Background()) {
+			d, err := json.Marshal(c)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(string(d))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().StringVar(&watchAddr, "addr", "", "serve the changes as server-sent events, eg :8080")
+}
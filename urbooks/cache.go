@@ -0,0 +1,25 @@
+package urbooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// CacheHandler serves the response cache stats of a library as json, it's
+// meant to be served at /api/cache next to the EventsHandler. The library
+// query param picks the library, the default library if it's empty.
+func CacheHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("library")
+	if name == "" {
+		name = DefaultLib().Name
+	}
+	lib := GetLib(name)
+	if lib == nil {
+		http.Error(w, fmt.Sprintf("%v is not a library", name), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lib.DB.Cache().Stats())
+}
@@ -204,7 +204,7 @@ func (c *cdbCmd) Import(input, cover, metaFile string) *cdbCmd {
 	return c
 }
 
-func (c *cdbCmd) Export(ids, dir, fmts string) *cdbCmd {
+func (c *cdbCmd) Export(ids, dir, fmts string) error {
 	c.setCdbCmd("export")
 	c.appendArgs(ids)
 
@@ -221,13 +221,24 @@ func (c *cdbCmd) Export(ids, dir, fmts string) *cdbCmd {
 	}
 
 	_, err := c.Run()
-	switch {
-	case err != nil:
-		log.Fatal(err)
-	case err == nil:
+	if err != nil {
+		return err
+	}
+	if c.verbose {
 		fmt.Println("export successful")
 	}
-	return c
+	return nil
+}
+
+func (c *cdbCmd) SetMetadata(id string, meta map[string]string) error {
+	_, err := c.setMetadataCmd(id, meta).Run()
+	if err != nil {
+		return err
+	}
+	if c.verbose {
+		fmt.Printf("updated metadata for %v\n", id)
+	}
+	return nil
 }
 
 func (c *cdbCmd) Remove(id string) *cdbCmd {
@@ -7,8 +7,10 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/ohzqq/urbooks-core/calibredb"
 	"github.com/spf13/viper"
 )
 
@@ -47,6 +49,32 @@ type config struct {
 	libCfg map[string]*libCfg
 }
 
+// Timeout is the deadline for a library query, from the timeout library
+// option, eg 30s. It's 0, no deadline, if it isn't set.
+func (c *config) Timeout() time.Duration {
+	d, err := time.ParseDuration(c.Opts["timeout"])
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// cacheDB sets the library's response cache from the cache library options.
+// cache: false turns it off, cache_entries limits the number of responses and
+// cache_size their total size in MB.
+func (c *config) cacheDB(cache *calibredb.Cache) {
+	if c.Opts["cache"] == "false" {
+		cache.Disable()
+		return
+	}
+	if n, err := strconv.Atoi(c.Opts["cache_entries"]); err == nil {
+		cache.SetMaxEntries(n)
+	}
+	if n, err := strconv.ParseInt(c.Opts["cache_size"], 10, 64); err == nil {
+		cache.SetMaxBytes(n << 20)
+	}
+}
+
 func (c *config) CatMin() int {
 	min, err := strconv.Atoi(c.Opts["cat_min"])
 	if err != nil {
@@ -0,0 +1,68 @@
+package urbooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ohzqq/urbooks-core/calibredb"
+)
+
+const eventsPing = 30 * time.Second
+
+// Subscribe returns a channel of the books added, modified or removed from the
+// library, it's closed when ctx is done.
+func (l *Library) Subscribe(ctx context.Context) <-chan calibredb.Change {
+	return l.DB.Subscribe(ctx)
+}
+
+// EventsHandler streams library changes as server-sent events, it's meant to
+// be served at /api/events. The library query param picks the library, the
+// default library if it's empty. Each event is named after the change type,
+// its data the change as json.
+func EventsHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("library")
+	if name == "" {
+		name = DefaultLib().Name
+	}
+	lib := GetLib(name)
+	if lib == nil {
+		http.Error(w, fmt.Sprintf("%v is not a library", name), http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ping := time.NewTicker(eventsPing)
+	defer ping.Stop()
+
+	changes := lib.Subscribe(r.Context())
+	for {
+		select {
+		case c, ok := <-changes:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(c)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", c.Type, data)
+		case <-ping.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,23 @@
+package urbooks
+
+import "net/url"
+
+// EachPage calls fn with every page of books for the request, following the
+// next links until the last page or until fn returns false.
+func (r *request) EachPage(fn func(BookResponse) bool) {
+	for {
+		resp := r.GetResponse()
+		if !fn(resp.ParseBooks()) {
+			return
+		}
+
+		next, err := url.Parse(resp.GetResponseLink("next"))
+		if err != nil || next.String() == "" {
+			return
+		}
+		if next.Query().Get("currentPage") == r.query.Get("currentPage") {
+			return
+		}
+		r.query = next.Query()
+	}
+}
@@ -0,0 +1,54 @@
+package urbooks
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ohzqq/urbooks-core/book"
+	"github.com/spf13/viper"
+)
+
+// CfgFormats registers the template files in the formats section of the
+// config as formats, keyed by format name:
+//
+//	formats:
+//	  sidecar:
+//	    template: ~/.config/urbooks/sidecar.tmpl
+//	    ext: .txt
+func CfgFormats(v *viper.Viper) {
+	if v == nil {
+		return
+	}
+	for name := range v.AllSettings() {
+		f := v.Sub(name)
+		if f == nil {
+			continue
+		}
+
+		path := f.GetString("template")
+		if strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				log.Fatal(err)
+			}
+			path = filepath.Join(home, path[2:])
+		}
+
+		tmpl, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("%v format template error: %v\n", name, err)
+		}
+
+		ext := f.GetString("ext")
+		if ext == "" {
+			ext = ".txt"
+		}
+
+		err = book.RegisterTemplate(name, ext, string(tmpl))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
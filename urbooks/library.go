@@ -58,6 +58,10 @@ func NewLibrary(name, path string) *Library {
 
 func (l *Library) ConnectDB() *Library {
 	l.DB = calibredb.NewLib(l.Path)
+	Cfg().cacheDB(l.DB.Cache())
+	if err := l.DB.SetLocale(Cfg().Opts["locale"]); err != nil {
+		log.Printf("library %v: %v\n", l.Name, err)
+	}
 	//l.pref = l.DB.Preferences
 	return l
 }
@@ -70,6 +74,7 @@ type dbPreferences struct {
 	HiddenCategories []string                   `json:"tag_browser_hidden_categories"`
 	DisplayFields    json.RawMessage            `json:"book_display_fields"`
 	SavedSearches    map[string]string          `json:"saved_searches"`
+	VirtualLibraries map[string]string          `json:"virtual_libraries"`
 	FieldMeta        map[string]map[string]bool `json:"field_metadata"`
 }
 
@@ -2,6 +2,7 @@ package urbooks
 
 import (
 	//"strings"
+	"context"
 	"encoding/json"
 	"log"
 
@@ -28,6 +29,12 @@ type request struct {
 }
 
 func Get(u string) ([]byte, error) {
+	return GetContext(context.Background(), u)
+}
+
+// GetContext is Get with a context, the query is canceled when ctx is done
+// or the configured timeout passes.
+func GetContext(ctx context.Context, u string) ([]byte, error) {
 	url, err := url.Parse(u)
 	if err != nil {
 		log.Fatal(err)
@@ -45,8 +52,18 @@ func Get(u string) ([]byte, error) {
 		lib = DefaultLib()
 		url.Query().Set("library", lib.Name)
 	}
+
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
 	//fmt.Printf("%v\n", url)
-	return lib.DB.Get(url.String()), nil
+	return lib.DB.GetContext(ctx, url.String()), nil
+}
+
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d := Cfg().Timeout(); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
 }
 
 func Query(u string) ([]byte, error) {
@@ -121,15 +138,30 @@ func (r *request) Get(key string) string {
 }
 
 func (r *request) Response() []byte {
-	lib := GetLib(r.lib)
+	return r.GetContext(context.Background())
+}
+
+// GetContext runs the request, it's canceled when ctx is done or the
+// configured timeout passes.
+func (r *request) GetContext(ctx context.Context) []byte {
+	lib := r.library
+	if lib == nil {
+		lib = GetLib(r.lib)
+	}
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
 	//fmt.Printf("%+v\n", lib)
-	return lib.DB.Get(r.String())
+	return lib.DB.GetContext(ctx, r.String())
 }
 
 func (r *request) GetResponse() Response {
+	return r.GetResponseContext(context.Background())
+}
+
+func (r *request) GetResponseContext(ctx context.Context) Response {
 	//var resp response
 	resp := Response{}
-	err := json.Unmarshal(r.library.DB.Get(r.String()), &resp)
+	err := json.Unmarshal(r.GetContext(ctx), &resp)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -181,6 +213,25 @@ func (r *request) Sort(sort string) *request {
 	return r
 }
 
+// VirtualLibrary restricts the request to the books of a calibre virtual
+// library.
+func (r *request) VirtualLibrary(name string) *request {
+	r.query.Add("vl", name)
+	return r
+}
+
+// Search only gets the books found by a calibre search expression.
+func (r *request) Search(expr string) *request {
+	r.query.Add("search", expr)
+	return r
+}
+
+// Locale sorts titles, names and categories for a locale.
+func (r *request) Locale(locale string) *request {
+	r.query.Add("locale", locale)
+	return r
+}
+
 func (r *request) Order(order string) *request {
 	r.query.Add("order", order)
 	return r
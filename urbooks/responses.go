@@ -48,10 +48,5 @@ func (r *Response) GetResponseLink(key string) string {
 }
 
 func GetResponse(r *request) Response {
-	resp := Response{}
-	err := json.Unmarshal(r.library.DB.Get(r.String()), &resp)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return resp
+	return r.GetResponse()
 }